@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultLRUSize = 10000
+
+// cacheSetup is the cache selected by the configuration. Breaker and Redis
+// are set only for the Redis backend; Redis is also used by the stores that
+// need Redis itself. Tiered is set when an L1 is enabled in front of Redis.
+type cacheSetup struct {
+	Backend string
+	Cache   cache.Cache
+	Breaker *cache.Breaker
+	Redis   *redis.Client
+	Tiered  *cache.Tiered
+
+	shared *cache.Redis
+}
+
+// Run keeps the cache healthy until ctx is canceled: it probes Redis while
+// the breaker is open and feeds invalidations from other instances to L1.
+func (s *cacheSetup) Run(ctx context.Context, interval time.Duration, logger *logrus.Logger) {
+	if s.Tiered != nil {
+		go s.shared.Listen(ctx, s.Tiered, interval, logger)
+	}
+	if s.Breaker != nil {
+		s.Breaker.Run(ctx, interval)
+	}
+}
+
+// newCache builds the cache selected by CACHE_BACKEND: "redis" (the default)
+// at REDIS_HOST, "lru" holding CACHE_LRU_SIZE entries in process, or "none".
+// Redis is wrapped in a circuit breaker, and an unreachable server only opens
+// the breaker: the service then runs from the database until Redis is back.
+// CACHE_L1_SIZE puts an in-process L1 of that many entries in front of Redis,
+// kept consistent across instances through Redis pub/sub.
+func newCache(logger *logrus.Logger) (*cacheSetup, error) {
+	switch backend := os.Getenv("CACHE_BACKEND"); backend {
+	case "", "redis":
+		timeout := durationFromEnv(logger, "REDIS_TIMEOUT", 500*time.Millisecond)
+		client := redis.NewClient(&redis.Options{
+			Addr:         os.Getenv("REDIS_HOST"),
+			DialTimeout:  timeout,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+			MaxRetries:   1,
+		})
+
+		shared := cache.NewRedis(client)
+		l1Size := intFromEnv(logger, "CACHE_L1_SIZE", 0)
+		if l1Size > 0 {
+			shared.WithInvalidations(cache.InvalidationChannel)
+		}
+
+		breaker := cache.NewBreaker(shared, cache.BreakerOptions{
+			Threshold: intFromEnv(logger, "CACHE_BREAKER_THRESHOLD", 5),
+			Cooldown:  durationFromEnv(logger, "CACHE_BREAKER_COOLDOWN", 10*time.Second),
+		}, logger)
+
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			logger.WithError(err).Warn("Redis is not reachable, starting without cache")
+			breaker.Open(err)
+		} else {
+			logger.Info("Connected to Redis")
+		}
+		setup := &cacheSetup{Backend: "redis", Cache: breaker, Breaker: breaker, Redis: client, shared: shared}
+		if l1Size > 0 {
+			setup.Tiered = cache.NewTiered(cache.NewLRU(l1Size), breaker, cache.TieredOptions{
+				TTL:         durationFromEnv(logger, "CACHE_L1_TTL", time.Minute),
+				FallbackTTL: durationFromEnv(logger, "CACHE_L1_FALLBACK_TTL", 5*time.Second),
+			})
+			setup.Cache = setup.Tiered
+			logger.Info("Using in-process L1 cache of ", l1Size, " entries in front of Redis")
+		}
+		return setup, nil
+	case "lru":
+		size := defaultLRUSize
+		if value := os.Getenv("CACHE_LRU_SIZE"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				return nil, fmt.Errorf("invalid CACHE_LRU_SIZE %q", value)
+			}
+			size = parsed
+		}
+		logger.Info("Using in-process LRU cache of ", size, " entries")
+		return &cacheSetup{Backend: backend, Cache: cache.NewLRU(size)}, nil
+	case "none":
+		logger.Warn("Caching is disabled")
+		return &cacheSetup{Backend: backend, Cache: cache.Noop{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q, expected redis, lru or none", backend)
+	}
+}
+
+func cachePolicyFromEnv(logger *logrus.Logger) service.CachePolicy {
+	defaults := service.DefaultCachePolicy
+	policy := service.CachePolicy{
+		TTL:          durationFromEnv(logger, "CACHE_PERSON_TTL", defaults.TTL),
+		NegativeTTL:  optionalDurationFromEnv(logger, "CACHE_NEGATIVE_TTL", defaults.NegativeTTL),
+		Jitter:       defaults.Jitter,
+		RefreshAhead: optionalDurationFromEnv(logger, "CACHE_REFRESH_AHEAD", defaults.RefreshAhead),
+		SearchTTL:    optionalDurationFromEnv(logger, "CACHE_SEARCH_TTL", defaults.SearchTTL),
+	}
+	if value := os.Getenv("CACHE_TTL_JITTER"); value != "" {
+		jitter, err := strconv.ParseFloat(value, 64)
+		if err != nil || jitter < 0 || jitter >= 1 {
+			logger.WithField("CACHE_TTL_JITTER", value).Warn("Invalid jitter, expected a fraction in [0, 1), using default")
+		} else {
+			policy.Jitter = jitter
+		}
+	}
+	if policy.RefreshAhead >= policy.TTL {
+		logger.Warn("CACHE_REFRESH_AHEAD is not shorter than CACHE_PERSON_TTL, every cache hit would reload the person")
+	}
+	return policy
+}
+
+func intFromEnv(logger *logrus.Logger, key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		logger.WithField(key, value).Warn("Invalid number, using default")
+		return fallback
+	}
+	return parsed
+}
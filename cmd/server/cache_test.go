@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/repository"
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type searchRepo struct {
+	repository.PersonRepositoryInterface
+	searches int
+}
+
+func (r *searchRepo) GetPeopleByName(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	r.searches++
+	return &models.PeoplePage{People: []models.Person{{IIN: "020304550283", Name: "Дулат Нурмеден"}}}, nil
+}
+
+func TestCachePolicyFromEnvZeroDisables(t *testing.T) {
+	t.Setenv("CACHE_NEGATIVE_TTL", "0")
+	t.Setenv("CACHE_REFRESH_AHEAD", "0s")
+	t.Setenv("CACHE_SEARCH_TTL", "0")
+
+	policy := cachePolicyFromEnv(logrus.New())
+	assert.Zero(t, policy.NegativeTTL)
+	assert.Zero(t, policy.RefreshAhead)
+	assert.Zero(t, policy.SearchTTL)
+
+	repo := &searchRepo{}
+	svc := service.NewPersonService(repo, logrus.New(), cache.NewLRU(100))
+	svc.CachePolicy = policy
+	query := models.PeopleQuery{Name: "Дулат", Mode: models.SearchModeContains, Page: 1, Limit: 10}
+	for i := 0; i < 2; i++ {
+		page, err := svc.GetPeopleByName(context.Background(), query)
+		assert.NoError(t, err)
+		assert.NotEqual(t, models.CacheHit, page.CacheStatus)
+	}
+	assert.Equal(t, 2, repo.searches, "every search reaches the database")
+}
+
+func TestCachePolicyFromEnvRejectsNegative(t *testing.T) {
+	t.Setenv("CACHE_NEGATIVE_TTL", "-1s")
+	t.Setenv("CACHE_SEARCH_TTL", "-5m")
+
+	policy := cachePolicyFromEnv(logrus.New())
+	assert.Equal(t, service.DefaultCachePolicy.NegativeTTL, policy.NegativeTTL)
+	assert.Equal(t, service.DefaultCachePolicy.SearchTTL, policy.SearchTTL)
+}
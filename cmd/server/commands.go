@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `usage:
+  server                       start the HTTP server
+  server migrate up            apply all pending migrations
+  server migrate down          roll back the last applied migration
+  server migrate status        show applied and pending migrations
+  server migrate goto N        migrate up or down to version N
+  server backfill-names        fill name search keys and parts of older rows
+  server gen-iin [flags]       print valid IINs (see gen-iin -h)
+  server import [flags] FILE   import people from a CSV or XLSX file (see import -h)
+  server export [flags]        export people as CSV, NDJSON or XLSX (see export -h)`
+
+// runCommand executes a CLI subcommand instead of starting the server.
+func runCommand(logger *logrus.Logger, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(logger, args[1:])
+	case "backfill-names":
+		return runBackfillNames(logger)
+	case "gen-iin":
+		return runGenIIN(args[1:])
+	case "import":
+		return runImport(logger, args[1:])
+	case "export":
+		return runExport(logger, args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
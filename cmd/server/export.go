@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/export"
+	"github.com/ddProgerGo/task-kaspi/internal/filter"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/repository"
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/ddProgerGo/task-kaspi/pkg/database"
+	"github.com/sirupsen/logrus"
+)
+
+func runExport(logger *logrus.Logger, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", export.FormatCSV, "output format: csv, ndjson or xlsx")
+	columns := flags.String("columns", "", "comma-separated columns to export (default: id,name,iin,phone,birth_date,sex,created_at)")
+	mask := flags.String("mask", "", "comma-separated columns to mask: iin, phone")
+	filterExpr := flags.String("filter", "", "filter expression, e.g. \"phone_prefix:+7701 or iin_prefix:0203\"")
+	sort := flags.String("sort", "", "comma-separated sort fields, minus for descending (default: id)")
+	includeDeleted := flags.Bool("include-deleted", false, "include soft-deleted people")
+	output := flags.String("o", "-", "output file, - for stdout")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	opts := export.Options{Format: *format, Columns: export.ParseList(*columns), Mask: export.ParseList(*mask)}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	query := models.PeopleQuery{Sort: *sort, IncludeDeleted: *includeDeleted}
+	if *filterExpr != "" {
+		expr, err := filter.Parse(*filterExpr)
+		if err != nil {
+			return fmt.Errorf("invalid -filter: %w", err)
+		}
+		query.Filter = &expr
+	}
+
+	var out io.Writer = os.Stdout
+	if *output == "-" {
+		// Keep log lines out of the exported data.
+		logger.SetOutput(os.Stderr)
+	} else {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	db, err := database.ConnectPostgres()
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	w, err := export.NewWriter(out, opts)
+	if err != nil {
+		return err
+	}
+
+	// Interrupting the export cancels its query.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	repo := repository.NewPersonRepository(db, logger, cache.Noop{})
+	if err := service.NewPersonService(repo, logger, cache.Noop{}).ExportPeople(ctx, query, w); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/utils"
+)
+
+func runGenIIN(args []string) error {
+	flags := flag.NewFlagSet("gen-iin", flag.ContinueOnError)
+	count := flags.Int("count", 10, "number of IINs to generate")
+	from := flags.String("from", "1950-01-01", "earliest birth date (YYYY-MM-DD)")
+	to := flags.String("to", time.Now().Format("2006-01-02"), "latest birth date (YYYY-MM-DD)")
+	sex := flags.String("sex", "", "male, female or empty for both")
+	century := flags.Int("century", 0, "restrict to a century: 1800, 1900 or 2000")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "random seed for reproducible output")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	fromDate, err := time.Parse("2006-01-02", *from)
+	if err != nil {
+		return fmt.Errorf("invalid -from date: %w", err)
+	}
+	toDate, err := time.Parse("2006-01-02", *to)
+	if err != nil {
+		return fmt.Errorf("invalid -to date: %w", err)
+	}
+
+	gen, err := utils.NewIINGenerator(utils.IINGeneratorOptions{
+		From:    fromDate,
+		To:      toDate,
+		Sex:     *sex,
+		Century: *century,
+	}, *seed)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	for i := 0; i < *count; i++ {
+		fmt.Fprintln(w, gen.Next())
+	}
+	return w.Flush()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ddProgerGo/task-kaspi/internal/repository"
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/ddProgerGo/task-kaspi/internal/sheet"
+	"github.com/ddProgerGo/task-kaspi/pkg/database"
+	"github.com/sirupsen/logrus"
+)
+
+func runImport(logger *logrus.Logger, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing")
+	format := flags.String("format", "", "input format, csv or xlsx (default: from the file extension)")
+	reportPath := flags.String("report", "", "write the per-row report to this .csv or .xlsx file")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected exactly one file to import\n%s", usage)
+	}
+
+	if *reportPath != "" && sheet.DetectFormat(*reportPath, "") == "" {
+		return fmt.Errorf("report file must end in .csv or .xlsx")
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = sheet.DetectFormat(path, "")
+	}
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	rows, err := sheet.NewReader(input, *format)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.ConnectPostgres()
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	// Interrupting the import cancels the batch being written; earlier
+	// batches stay committed.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The server's cache is shared through Redis, and imported IINs may be
+	// cached there as not found, so the import invalidates them.
+	caches, err := newCache(logger)
+	if err != nil {
+		return err
+	}
+
+	repo := repository.NewPersonRepository(db, logger, caches.Cache)
+	report, importErr := service.NewPersonService(repo, logger, caches.Cache).ImportPeople(ctx, rows, *dryRun)
+	if report == nil {
+		return importErr
+	}
+
+	fmt.Printf("rows: %d, accepted: %d, duplicates: %d, rejected: %d, not processed: %d\n",
+		report.Total, report.Accepted, report.Duplicates, report.Rejected, report.NotProcessed)
+	if *dryRun {
+		fmt.Println("dry run: nothing was written")
+	}
+
+	// A failed import still reports which rows were written.
+	if *reportPath != "" {
+		if err := writeImportReport(*reportPath, report.Records()); err != nil {
+			return err
+		}
+	}
+	return importErr
+}
+
+func writeImportReport(path string, records [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w, err := sheet.NewWriter(file, sheet.DetectFormat(path, ""))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
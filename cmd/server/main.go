@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,12 +10,13 @@ import (
 	"time"
 
 	"github.com/ddProgerGo/task-kaspi/internal/handler"
+	"github.com/ddProgerGo/task-kaspi/internal/jobs"
 	"github.com/ddProgerGo/task-kaspi/internal/middleware"
+	"github.com/ddProgerGo/task-kaspi/internal/pagination"
 	"github.com/ddProgerGo/task-kaspi/internal/repository"
 	"github.com/ddProgerGo/task-kaspi/internal/service"
 	"github.com/ddProgerGo/task-kaspi/pkg/database"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
 
 	_ "github.com/ddProgerGo/task-kaspi/docs"
@@ -30,23 +31,31 @@ func main() {
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.InfoLevel)
 
+	if len(os.Args) > 1 {
+		if err := runCommand(logger, os.Args[1:]); err != nil {
+			logger.WithError(err).Fatal("Command failed")
+		}
+		return
+	}
+
 	db, err := database.ConnectPostgres()
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to connect to database")
 	}
 
-	cache := redis.NewClient(&redis.Options{
-		Addr: os.Getenv("REDIS_HOST"),
-	})
-
-	_, err = cache.Ping(context.Background()).Result()
+	caches, err := newCache(logger)
 	if err != nil {
-		logger.WithError(err).Fatal("Failed to connect to Redis")
+		logger.WithError(err).Fatal("Failed to set up cache")
 	}
 
-	log.Println("Redis подключен")
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load migrations")
+	}
 
-	database.RunMigrations(db)
+	if err := migrator.Up(context.Background()); err != nil {
+		logger.WithError(err).Fatal("Failed to run migrations")
+	}
 
 	if err := db.Ping(); err != nil {
 		logger.WithError(err).Fatal("Database is not reachable")
@@ -54,26 +63,83 @@ func main() {
 
 	logger.Info("Connected to database successfully")
 
-	repo := repository.NewPersonRepository(db, logger, cache)
-	service := service.NewPersonService(repo, logger, cache)
-	handler := handler.NewPersonHandler(service, logger)
+	repo := repository.NewPersonRepository(db, logger, caches.Cache)
+	service := service.NewPersonService(repo, logger, caches.Cache)
+	service.Timeouts = timeoutsFromEnv(logger)
+	service.CachePolicy = cachePolicyFromEnv(logger)
+
+	cursorSecret := os.Getenv("CURSOR_SECRET")
+	if cursorSecret == "" {
+		logger.Warn("CURSOR_SECRET is not set, pagination cursors will not survive a restart")
+	}
+	health := handler.NewHealthHandler(db, caches.Backend, caches.Breaker, caches.Tiered, logger)
+	handler := handler.NewPersonHandler(service, logger, pagination.NewCodec([]byte(cursorSecret)))
+
+	idempotency := repository.NewIdempotencyRepository(db, logger, caches.Redis, caches.Breaker)
+	idempotencyTTL := durationFromEnv(logger, "IDEMPOTENCY_TTL", 24*time.Hour)
+	idempotencyLease := durationFromEnv(logger, "IDEMPOTENCY_LEASE", service.Timeouts.Write+30*time.Second)
 
 	router := gin.Default()
 
 	router.Use(middleware.ErrorHandlingMiddleware(logger))
+	router.Use(middleware.AdminMiddleware(os.Getenv("ADMIN_TOKEN")))
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	router.GET("/health/live", health.Live)
+	router.GET("/health/ready", health.Ready)
+	router.GET("/health/cache", health.Cache)
+
 	router.GET("/iin_check/:iin", handler.CheckIIN)
-	router.POST("/people/info", handler.SavePerson)
+	router.POST("/iin_check/batch", handler.BatchCheckIIN)
+	router.GET("/bin_check/:bin", handler.CheckBIN)
+	router.GET("/id_check/:number", handler.CheckID)
+	router.GET("/people/info", handler.ListPeople)
+	router.POST("/people/info", middleware.Idempotency(idempotency, idempotencyTTL, idempotencyLease, logger), handler.SavePerson)
+	router.POST("/people/import", handler.ImportPeople)
+	router.GET("/people/export", handler.ExportPeople)
 	router.GET("/people/info/iin/:iin", handler.GetPersonByIIN)
-	router.GET("/people/info/phone/:name", handler.GetPeopleByName)
+	router.PUT("/people/info/iin/:iin", handler.UpdatePerson)
+	router.PATCH("/people/info/iin/:iin", handler.PatchPerson)
+	router.DELETE("/people/info/iin/:iin", handler.DeletePerson)
+	router.POST("/people/info/iin/:iin/restore", middleware.RequireAdmin(), handler.RestorePerson)
+	router.GET("/people/info/name/:name", handler.GetPeopleByName)
+	router.GET("/people/info/by-phone/:phone", handler.GetPeopleByPhone)
+	// Deprecated: searches by name despite its path, kept for existing clients.
+	router.GET("/people/info/phone/:name", middleware.Deprecated("/people/info/name/:name"), handler.GetPeopleByName)
+
+	// Request contexts derive from requestCtx, which is canceled when a
+	// graceful shutdown runs out of time, aborting the SQL still in flight.
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 
 	server := &http.Server{
-		Addr:    os.Getenv("ADDRESS"),
-		Handler: router,
+		Addr:        os.Getenv("ADDRESS"),
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return requestCtx },
 	}
 
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	purgeJob := jobs.NewPurgeJob(
+		service,
+		logger,
+		durationFromEnv(logger, "PURGE_INTERVAL", time.Hour),
+		durationFromEnv(logger, "SOFT_DELETE_RETENTION", 30*24*time.Hour),
+	)
+	go purgeJob.Run(jobCtx)
+
+	go caches.Run(jobCtx, durationFromEnv(logger, "CACHE_PROBE_INTERVAL", 5*time.Second), logger)
+
+	// Rows saved before the name columns existed are filled in the
+	// background; until then they are missing from name searches.
+	go func() {
+		if err := backfillNameFields(jobCtx, db, logger); err != nil && jobCtx.Err() == nil {
+			logger.WithError(err).Error("Failed to backfill name fields")
+		}
+	}()
+
 	go func() {
 		logger.Info("Server is starting on port 8080")
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -87,10 +153,13 @@ func main() {
 
 	logger.Warn("Shutdown signal received, stopping server...")
 
+	stopJobs()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
+		cancelRequests()
 		logger.WithError(err).Error("Server shutdown failed")
 	} else {
 		logger.Info("Server stopped gracefully")
@@ -103,3 +172,45 @@ func main() {
 	}
 
 }
+
+// timeoutsFromEnv reads the per-operation timeouts, keeping the defaults for
+// unset variables.
+func timeoutsFromEnv(logger *logrus.Logger) service.Timeouts {
+	defaults := service.DefaultTimeouts
+	return service.Timeouts{
+		Read:   durationFromEnv(logger, "TIMEOUT_READ", defaults.Read),
+		Search: durationFromEnv(logger, "TIMEOUT_SEARCH", defaults.Search),
+		Write:  durationFromEnv(logger, "TIMEOUT_WRITE", defaults.Write),
+		Bulk:   durationFromEnv(logger, "TIMEOUT_BULK", defaults.Bulk),
+	}
+}
+
+func durationFromEnv(logger *logrus.Logger, key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		logger.WithField(key, value).Warn("Invalid duration, using default")
+		return fallback
+	}
+	return duration
+}
+
+// optionalDurationFromEnv is durationFromEnv for settings that zero turns
+// off: it accepts 0 and rejects only negative durations.
+func optionalDurationFromEnv(logger *logrus.Logger, key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		logger.WithField(key, value).Warn("Invalid duration, using default")
+		return fallback
+	}
+	return duration
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/repository"
+	"github.com/ddProgerGo/task-kaspi/pkg/database"
+	"github.com/sirupsen/logrus"
+)
+
+func runMigrate(logger *logrus.Logger, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate action\n%s", usage)
+	}
+
+	db, err := database.ConnectPostgres()
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	target := 0
+
+	switch args[0] {
+	case "up":
+		target = migrator.Latest()
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "goto":
+		if len(args) < 2 {
+			return fmt.Errorf("missing target version\n%s", usage)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid target version %q", args[1])
+		}
+		target = version
+		err = migrator.Goto(ctx, version)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	default:
+		return fmt.Errorf("unknown migrate action %q\n%s", args[0], usage)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Migrations completed successfully")
+
+	if target >= nameFieldsVersion {
+		return backfillNameFields(ctx, db, logger)
+	}
+	return nil
+}
+
+// nameFieldsVersion is the migration adding the name part columns that
+// BackfillNameFields fills.
+const nameFieldsVersion = 8
+
+func runBackfillNames(logger *logrus.Logger) error {
+	db, err := database.ConnectPostgres()
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return backfillNameFields(ctx, db, logger)
+}
+
+// backfillNameFields fills the search key and name parts of people saved
+// before those columns existed. It is a no-op once every row is filled, and
+// an interrupted run is resumed by the next one.
+func backfillNameFields(ctx context.Context, db *sql.DB, logger *logrus.Logger) error {
+	repo := repository.NewPersonRepository(db, logger, cache.Noop{})
+	updated, err := repo.BackfillNameFields(ctx, 1000)
+	if err != nil {
+		return fmt.Errorf("backfill name fields: %w", err)
+	}
+	logger.Info("Name fields are filled, rows updated: ", updated)
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Missing:
+			state = "applied (file missing)"
+		case status.ChecksumMismatch:
+			state = "applied (checksum mismatch)"
+		case status.Applied:
+			state = "applied"
+		}
+
+		appliedAt := "-"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
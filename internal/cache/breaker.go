@@ -0,0 +1,283 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnavailable is returned instead of calling the backend while the
+// breaker is open.
+var ErrUnavailable = errors.New("cache: unavailable")
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// maxPendingDeletes bounds the invalidations remembered while the backend is
+// down.
+const maxPendingDeletes = 10000
+
+// Pinger is implemented by backends that can check their connection.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// BreakerOptions configures a Breaker.
+type BreakerOptions struct {
+	// Threshold is the number of consecutive failures that opens the breaker.
+	Threshold int
+	// Cooldown is how long the breaker stays open before letting a probe
+	// through.
+	Cooldown time.Duration
+}
+
+// Health describes the state of a Breaker.
+type Health struct {
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// Breaker is a circuit breaker around another Cache. After Threshold
+// consecutive failures it opens and fails every call with ErrUnavailable
+// without touching the backend, so that an outage costs callers nothing but
+// cache misses. Once Cooldown has passed a single call is let through as a
+// probe: success closes the breaker, failure keeps it open for another
+// cooldown.
+//
+// Deletes skipped or failed while the backend is unreachable are remembered
+// and replayed when it recovers, so that entries invalidated during an outage
+// are not served afterwards.
+type Breaker struct {
+	next   Cache
+	opts   BreakerOptions
+	logger *logrus.Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	lastErr   error
+	pending   map[string]struct{}
+	overflown bool
+}
+
+func NewBreaker(next Cache, opts BreakerOptions, logger *logrus.Logger) *Breaker {
+	if opts.Threshold < 1 {
+		opts.Threshold = 1
+	}
+	return &Breaker{
+		next:    next,
+		opts:    opts,
+		logger:  logger,
+		now:     time.Now,
+		state:   StateClosed,
+		pending: map[string]struct{}{},
+	}
+}
+
+func (b *Breaker) Get(ctx context.Context, key string) ([]byte, error) {
+	if !b.allow() {
+		return nil, ErrUnavailable
+	}
+	value, err := b.next.Get(ctx, key)
+	b.record(ctx, err)
+	return value, err
+}
+
+func (b *Breaker) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if !b.allow() {
+		return ErrUnavailable
+	}
+	err := b.next.Set(ctx, key, value, ttl)
+	b.record(ctx, err)
+	return err
+}
+
+func (b *Breaker) Delete(ctx context.Context, keys ...string) error {
+	if !b.allow() {
+		b.remember(keys)
+		return ErrUnavailable
+	}
+	err := b.next.Delete(ctx, keys...)
+	b.record(ctx, err)
+	if err != nil {
+		b.remember(keys)
+	}
+	return err
+}
+
+func (b *Breaker) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if !b.allow() {
+		return nil, ErrUnavailable
+	}
+	values, err := b.next.GetMulti(ctx, keys)
+	b.record(ctx, err)
+	return values, err
+}
+
+// Ping checks the backend through the breaker, so it serves as a probe once
+// the cooldown has passed. Backends that cannot be pinged are reported as
+// reachable.
+func (b *Breaker) Ping(ctx context.Context) error {
+	pinger, ok := b.next.(Pinger)
+	if !ok {
+		return nil
+	}
+	if !b.allow() {
+		return ErrUnavailable
+	}
+	err := pinger.Ping(ctx)
+	b.record(ctx, err)
+	return err
+}
+
+// Open forces the breaker open, as when the backend is known to be down.
+func (b *Breaker) Open(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trip(err)
+}
+
+// Run probes the backend every interval while the breaker is open, so that
+// it recovers without waiting for traffic. It blocks until ctx is canceled.
+func (b *Breaker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if b.Health().State != StateClosed {
+			probeCtx, cancel := context.WithTimeout(ctx, interval)
+			b.Ping(probeCtx)
+			cancel()
+		}
+	}
+}
+
+func (b *Breaker) Health() Health {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := Health{State: b.state, Failures: b.failures}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		health.OpenedAt = &openedAt
+	}
+	if b.lastErr != nil {
+		health.LastError = b.lastErr.Error()
+	}
+	return health
+}
+
+// allow reports whether a call may reach the backend, turning the first call
+// after the cooldown into the probe.
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		if b.now().Sub(b.openedAt) >= b.opts.Cooldown {
+			b.state = StateHalfOpen
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Breaker) record(ctx context.Context, err error) {
+	if err != nil && err != ErrMiss && ctx.Err() != nil {
+		// The caller gave up; that says nothing about the backend. A probe
+		// cut short this way is retried after another cooldown.
+		b.mu.Lock()
+		if b.state == StateHalfOpen {
+			b.state, b.openedAt = StateOpen, b.now()
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	b.mu.Lock()
+	if err == nil || err == ErrMiss {
+		recovered := b.state != StateClosed
+		b.state, b.failures, b.lastErr = StateClosed, 0, nil
+		b.mu.Unlock()
+		if recovered {
+			b.logger.Info("Cache backend recovered, closing circuit breaker")
+			b.replay(ctx)
+		}
+		return
+	}
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastErr = err
+	if b.state == StateHalfOpen || b.failures >= b.opts.Threshold {
+		b.trip(err)
+	}
+}
+
+func (b *Breaker) trip(err error) {
+	if b.state != StateOpen {
+		b.logger.WithError(err).Warn("Cache backend unavailable, opening circuit breaker")
+	}
+	b.state = StateOpen
+	b.openedAt = b.now()
+	if err != nil {
+		b.lastErr = err
+	}
+}
+
+func (b *Breaker) remember(keys []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		if len(b.pending) >= maxPendingDeletes {
+			b.overflown = true
+			return
+		}
+		b.pending[key] = struct{}{}
+	}
+}
+
+// replay deletes the keys invalidated while the backend was down.
+func (b *Breaker) replay(ctx context.Context) {
+	b.mu.Lock()
+	keys := make([]string, 0, len(b.pending))
+	for key := range b.pending {
+		keys = append(keys, key)
+	}
+	overflown := b.overflown
+	b.pending, b.overflown = map[string]struct{}{}, false
+	b.mu.Unlock()
+
+	if overflown {
+		b.logger.Warn("Too many invalidations missed during the cache outage, some entries may stay stale until they expire")
+	}
+	if len(keys) == 0 {
+		return
+	}
+	if err := b.next.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		b.logger.WithError(err).Error("Failed to replay cache invalidations")
+		b.remember(keys)
+		return
+	}
+	b.logger.Info("Replayed cache invalidations: ", len(keys))
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyCache is an LRU that fails every call while down is set.
+type flakyCache struct {
+	*LRU
+	down  bool
+	calls int
+}
+
+var errDown = errors.New("connection refused")
+
+func (f *flakyCache) Get(ctx context.Context, key string) ([]byte, error) {
+	f.calls++
+	if f.down {
+		return nil, errDown
+	}
+	return f.LRU.Get(ctx, key)
+}
+
+func (f *flakyCache) Delete(ctx context.Context, keys ...string) error {
+	f.calls++
+	if f.down {
+		return errDown
+	}
+	return f.LRU.Delete(ctx, keys...)
+}
+
+func TestBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	backend := &flakyCache{LRU: NewLRU(10)}
+	breaker := NewBreaker(backend, BreakerOptions{Threshold: 2, Cooldown: time.Minute}, logrus.New())
+	breaker.now = func() time.Time { return now }
+
+	assert.NoError(t, breaker.Set(ctx, "a", []byte("1"), 0))
+	backend.down = true
+
+	_, err := breaker.Get(ctx, "a")
+	assert.Equal(t, errDown, err)
+	assert.Equal(t, StateClosed, breaker.Health().State)
+	_, err = breaker.Get(ctx, "a")
+	assert.Equal(t, errDown, err)
+	assert.Equal(t, StateOpen, breaker.Health().State)
+
+	// Open: calls fail fast without reaching the backend, and deletes are
+	// remembered.
+	calls := backend.calls
+	_, err = breaker.Get(ctx, "a")
+	assert.Equal(t, ErrUnavailable, err)
+	assert.Equal(t, ErrUnavailable, breaker.Delete(ctx, "a"))
+	assert.Equal(t, calls, backend.calls)
+
+	// A failed probe after the cooldown keeps the breaker open.
+	now = now.Add(time.Minute)
+	_, err = breaker.Get(ctx, "a")
+	assert.Equal(t, errDown, err)
+	assert.Equal(t, StateOpen, breaker.Health().State)
+	_, err = breaker.Get(ctx, "a")
+	assert.Equal(t, ErrUnavailable, err)
+
+	// A successful probe closes it and replays the missed delete.
+	backend.down = false
+	now = now.Add(time.Minute)
+	_, err = breaker.Get(ctx, "b")
+	assert.Equal(t, ErrMiss, err)
+	assert.Equal(t, Health{State: StateClosed}, breaker.Health())
+	_, err = breaker.Get(ctx, "a")
+	assert.Equal(t, ErrMiss, err, "the delete missed during the outage is replayed")
+}
+
+func TestBreakerIgnoresCanceledCalls(t *testing.T) {
+	backend := &flakyCache{LRU: NewLRU(10), down: true}
+	breaker := NewBreaker(backend, BreakerOptions{Threshold: 1, Cooldown: time.Minute}, logrus.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := breaker.Get(ctx, "a")
+	assert.Equal(t, errDown, err)
+	assert.Equal(t, StateClosed, breaker.Health().State)
+}
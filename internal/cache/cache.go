@@ -0,0 +1,43 @@
+// Package cache provides a small key-value cache abstraction with Redis,
+// in-process LRU and no-op backends, and the key scheme shared by everything
+// that caches.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is not cached.
+var ErrMiss = errors.New("cache: miss")
+
+// Cache stores encoded values under string keys. A zero TTL keeps a value
+// until it is deleted or evicted.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// GetMulti returns the cached values among keys; missing keys are absent
+	// from the result.
+	GetMulti(ctx context.Context, keys []string) (map[string][]byte, error)
+}
+
+// GetJSON decodes the value cached under key into dest.
+func GetJSON(ctx context.Context, c Cache, key string, dest interface{}) error {
+	data, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// SetJSON caches the JSON encoding of value under key.
+func SetJSON(ctx context.Context, c Cache, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, data, ttl)
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// Group coalesces concurrent loads of the same key into one, in the manner of
+// golang.org/x/sync/singleflight. The load runs on its own goroutine, so a
+// caller that gives up does not cancel it for the others waiting on it; the
+// load function is responsible for bounding its own context.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// Do returns the result of load for key, joining a load already in flight
+// instead of starting another. It returns ctx's error if ctx is done first.
+func (g *Group) Do(ctx context.Context, key string, load func() (interface{}, error)) (interface{}, error) {
+	c := g.start(key, load)
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Go starts load for key in the background unless one is already in flight.
+func (g *Group) Go(key string, load func() (interface{}, error)) {
+	g.start(key, load)
+}
+
+func (g *Group) start(key string, load func() (interface{}, error)) *call {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c, ok := g.calls[key]; ok {
+		return c
+	}
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+
+	go func() {
+		c.value, c.err = load()
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	return c
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// InvalidationChannel is the Redis channel carrying deleted keys between
+// instances. It is versioned with the keys it carries.
+const InvalidationChannel = Version + ":invalidations"
+
+// InvalidationListener receives the keys deleted by any instance and the
+// state of the subscription; Tiered is one.
+type InvalidationListener interface {
+	Evict(keys ...string)
+	Subscribed(subscribed bool)
+}
+
+// Listen subscribes to the invalidation channel and passes the keys
+// announced there to listener until ctx is canceled. The connection is pinged
+// when idle for interval; when it drops, the listener is told so and the
+// subscription is retried every interval.
+func (r *Redis) Listen(ctx context.Context, listener InvalidationListener, interval time.Duration, logger *logrus.Logger) {
+	warn := true
+	for ctx.Err() == nil {
+		subscribed, err := r.listen(ctx, listener, interval)
+		listener.Subscribed(false)
+		if ctx.Err() != nil {
+			return
+		}
+		// Warn once per outage rather than on every retry.
+		if subscribed || warn {
+			logger.WithError(err).Warn("Cache invalidation subscription lost, retrying")
+		}
+		warn = false
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// listen receives invalidations until the subscription fails, and reports
+// whether it had been established.
+func (r *Redis) listen(ctx context.Context, listener InvalidationListener, interval time.Duration) (subscribed bool, err error) {
+	pubsub := r.client.Subscribe(ctx, r.channel)
+	defer pubsub.Close()
+
+	pinged := false
+	for {
+		message, err := pubsub.ReceiveTimeout(ctx, interval)
+		if err != nil {
+			if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() && !pinged {
+				// Idle for interval: make sure the connection is still there.
+				if err := pubsub.Ping(ctx); err != nil {
+					return subscribed, err
+				}
+				pinged = true
+				continue
+			}
+			return subscribed, err
+		}
+		pinged = false
+
+		switch message := message.(type) {
+		case *redis.Subscription:
+			if message.Kind == "subscribe" {
+				subscribed = true
+				listener.Subscribed(true)
+			}
+		case *redis.Message:
+			var keys []string
+			if err := json.Unmarshal([]byte(message.Payload), &keys); err != nil {
+				continue
+			}
+			listener.Evict(keys...)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cache
+
+import "strings"
+
+// Version prefixes every key. Bump it whenever the encoding of a cached value
+// changes, so that entries written by older releases are ignored instead of
+// being misread.
+const Version = "v2"
+
+// Key joins a kind of entry and its identifying parts into a versioned key,
+// e.g. "v2:person:020304550283".
+func Key(kind string, parts ...string) string {
+	return Version + ":" + kind + ":" + strings.Join(parts, ":")
+}
+
+// PersonKey is the key of a person cached by IIN.
+func PersonKey(iin string) string {
+	return Key("person", iin)
+}
+
+// SearchGenerationKey holds the current generation of cached search pages.
+// Pages are cached under their generation, so deleting it on every write
+// orphans all of them at once.
+var SearchGenerationKey = Key("search", "generation")
+
+// SearchPageKey is the key of a search page cached in a generation; query
+// identifies the search.
+func SearchPageKey(generation, query string) string {
+	return Key("search", generation, query)
+}
+
+// PersonKeys returns the keys of the people with the given IINs.
+func PersonKeys(iins ...string) []string {
+	keys := make([]string, len(iins))
+	for i, iin := range iins {
+		keys[i] = PersonKey(iin)
+	}
+	return keys
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lru := NewLRU(2)
+	lru.now = func() time.Time { return now }
+
+	assert.NoError(t, lru.Set(ctx, "a", []byte("1"), 0))
+	assert.NoError(t, lru.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// Reading a makes b the least recently used, so c evicts b.
+	value, err := lru.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+	assert.NoError(t, lru.Set(ctx, "c", []byte("3"), time.Minute))
+	assert.Equal(t, 2, lru.Len())
+
+	_, err = lru.Get(ctx, "b")
+	assert.Equal(t, ErrMiss, err)
+
+	values, err := lru.GetMulti(ctx, []string{"a", "b", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("1"), "c": []byte("3")}, values)
+
+	now = now.Add(time.Minute)
+	_, err = lru.Get(ctx, "c")
+	assert.Equal(t, ErrMiss, err, "expired entries are misses")
+	_, err = lru.Get(ctx, "a")
+	assert.NoError(t, err, "entries without a TTL do not expire")
+
+	assert.NoError(t, lru.Delete(ctx, "a", "missing"))
+	assert.Equal(t, 0, lru.Len())
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "v2:person:020304550283", PersonKey("020304550283"))
+	assert.Equal(t, []string{"v2:person:1", "v2:person:2"}, PersonKeys("1", "2"))
+	assert.Equal(t, "v2:idempotency:abc", Key("idempotency", "abc"))
+}
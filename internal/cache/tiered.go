@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tieredStripes is the number of stripes the keys of a Tiered cache are
+// spread over to order L1 refills against invalidations.
+const tieredStripes = 64
+
+// TieredOptions configures a Tiered cache.
+type TieredOptions struct {
+	// TTL bounds how long an entry stays in L1 while invalidations from
+	// other instances are being received.
+	TTL time.Duration
+	// FallbackTTL bounds it while they are not, since a write on another
+	// instance then goes unnoticed until the entry expires.
+	FallbackTTL time.Duration
+}
+
+// TierStats counts the lookups answered by one tier.
+type TierStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// TieredStats describes a Tiered cache.
+type TieredStats struct {
+	L1         TierStats `json:"l1"`
+	L2         TierStats `json:"l2"`
+	L1Entries  int       `json:"l1_entries"`
+	Subscribed bool      `json:"subscribed"`
+}
+
+// Tiered is a two-tier Cache: a small in-process L1 in front of a shared L2.
+// Reads are served from L1 when possible and fill it from L2; writes and
+// deletes go to both tiers.
+//
+// L1 is private to the instance, so a delete has to reach the L1 of every
+// other instance too. The L2 announces deletes (see Redis.WithInvalidations)
+// and each instance feeds the announcements to Evict and the state of its
+// subscription to Subscribed. While not subscribed, entries are kept in L1
+// for FallbackTTL only.
+//
+// A value read from L2 is copied into L1 only if its key was neither written
+// nor invalidated during the read, so that a value read just before a delete
+// is not put back into L1 after it.
+type Tiered struct {
+	l1   *LRU
+	l2   Cache
+	opts TieredOptions
+
+	stripes [tieredStripes]tieredStripe
+
+	subscribed atomic.Bool
+	l1Hits     atomic.Int64
+	l1Misses   atomic.Int64
+	l2Hits     atomic.Int64
+	l2Misses   atomic.Int64
+}
+
+// tieredStripe counts the writes and invalidations of its keys in L1.
+type tieredStripe struct {
+	mu    sync.Mutex
+	epoch uint64
+}
+
+func NewTiered(l1 *LRU, l2 Cache, opts TieredOptions) *Tiered {
+	return &Tiered{l1: l1, l2: l2, opts: opts}
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	epoch := t.epoch(key)
+	if value, err := t.l1.Get(ctx, key); err == nil {
+		t.l1Hits.Add(1)
+		return value, nil
+	}
+	t.l1Misses.Add(1)
+
+	value, err := t.l2.Get(ctx, key)
+	switch err {
+	case nil:
+		t.l2Hits.Add(1)
+		t.fill(ctx, key, value, epoch)
+	case ErrMiss:
+		t.l2Misses.Add(1)
+	}
+	return value, err
+}
+
+// Set and Delete change L2 before L1, so that a read that sees the old L1
+// state either reads L2 after the change or sees its epoch move.
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := t.l2.Set(ctx, key, value, ttl)
+	stripe := t.stripe(key)
+	stripe.mu.Lock()
+	t.l1.Set(ctx, key, value, t.l1TTL(ttl))
+	stripe.epoch++
+	stripe.mu.Unlock()
+	return err
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	err := t.l2.Delete(ctx, keys...)
+	t.invalidate(keys)
+	return err
+}
+
+func (t *Tiered) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	epochs := make(map[string]uint64, len(keys))
+	for _, key := range keys {
+		epochs[key] = t.epoch(key)
+	}
+
+	values, _ := t.l1.GetMulti(ctx, keys)
+	t.l1Hits.Add(int64(len(values)))
+
+	var missing []string
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	t.l1Misses.Add(int64(len(missing)))
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	found, err := t.l2.GetMulti(ctx, missing)
+	if err != nil {
+		return values, err
+	}
+	t.l2Hits.Add(int64(len(found)))
+	t.l2Misses.Add(int64(len(missing) - len(found)))
+	for key, value := range found {
+		values[key] = value
+		t.fill(ctx, key, value, epochs[key])
+	}
+	return values, nil
+}
+
+// Evict drops keys from L1 only, on an invalidation from another instance.
+// The other instance has already deleted them from L2.
+func (t *Tiered) Evict(keys ...string) {
+	t.invalidate(keys)
+}
+
+// Subscribed records whether invalidations are being received. L1 is purged
+// whenever that changes: entries cached before a drop would outlive
+// invalidations missed during it, and entries cached before a resubscription
+// may already have missed some.
+func (t *Tiered) Subscribed(subscribed bool) {
+	if t.subscribed.Swap(subscribed) != subscribed {
+		for i := range t.stripes {
+			t.stripes[i].mu.Lock()
+		}
+		t.l1.Purge()
+		for i := range t.stripes {
+			t.stripes[i].epoch++
+			t.stripes[i].mu.Unlock()
+		}
+	}
+}
+
+func (t *Tiered) Stats() TieredStats {
+	return TieredStats{
+		L1:         TierStats{Hits: t.l1Hits.Load(), Misses: t.l1Misses.Load()},
+		L2:         TierStats{Hits: t.l2Hits.Load(), Misses: t.l2Misses.Load()},
+		L1Entries:  t.l1.Len(),
+		Subscribed: t.subscribed.Load(),
+	}
+}
+
+// l1TTL caps ttl, zero meaning none, by the L1 TTL currently in force.
+func (t *Tiered) l1TTL(ttl time.Duration) time.Duration {
+	limit := t.opts.FallbackTTL
+	if t.subscribed.Load() {
+		limit = t.opts.TTL
+	}
+	if ttl <= 0 || ttl > limit {
+		return limit
+	}
+	return ttl
+}
+
+func (t *Tiered) stripe(key string) *tieredStripe {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return &t.stripes[hash.Sum32()%tieredStripes]
+}
+
+// epoch returns the epoch of key, to be passed to fill after reading L2.
+func (t *Tiered) epoch(key string) uint64 {
+	stripe := t.stripe(key)
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	return stripe.epoch
+}
+
+// fill copies a value read from L2 into L1, unless key was written or
+// invalidated since its epoch was taken.
+func (t *Tiered) fill(ctx context.Context, key string, value []byte, epoch uint64) {
+	stripe := t.stripe(key)
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	if stripe.epoch == epoch {
+		t.l1.Set(ctx, key, value, t.l1TTL(0))
+	}
+}
+
+// invalidate drops keys from L1 and moves their epochs, so that reads of L2
+// still in flight do not put them back.
+func (t *Tiered) invalidate(keys []string) {
+	for _, key := range keys {
+		stripe := t.stripe(key)
+		stripe.mu.Lock()
+		t.l1.Delete(context.Background(), key)
+		stripe.epoch++
+		stripe.mu.Unlock()
+	}
+}
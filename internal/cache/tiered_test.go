@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTiered(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	l1, l2 := NewLRU(10), NewLRU(10)
+	l1.now = func() time.Time { return now }
+	l2.now = l1.now
+	tiered := NewTiered(l1, l2, TieredOptions{TTL: time.Minute, FallbackTTL: 5 * time.Second})
+	tiered.Subscribed(true)
+
+	// Another instance wrote a; the first read fills L1 from L2.
+	assert.NoError(t, l2.Set(ctx, "a", []byte("1"), time.Hour))
+	for i := 0; i < 3; i++ {
+		value, err := tiered.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("1"), value)
+	}
+	_, err := tiered.Get(ctx, "missing")
+	assert.Equal(t, ErrMiss, err)
+	assert.Equal(t, TieredStats{
+		L1:         TierStats{Hits: 2, Misses: 2},
+		L2:         TierStats{Hits: 1, Misses: 1},
+		L1Entries:  1,
+		Subscribed: true,
+	}, tiered.Stats())
+
+	// A write on another instance reaches this one as an invalidation.
+	assert.NoError(t, l2.Set(ctx, "a", []byte("2"), time.Hour))
+	value, _ := tiered.Get(ctx, "a")
+	assert.Equal(t, []byte("1"), value)
+	tiered.Evict("a")
+	value, _ = tiered.Get(ctx, "a")
+	assert.Equal(t, []byte("2"), value)
+
+	// L1 entries live for the L1 TTL, whatever the TTL in L2.
+	now = now.Add(time.Minute)
+	_, err = l1.Get(ctx, "a")
+	assert.Equal(t, ErrMiss, err)
+
+	// Losing the subscription purges L1 and shortens its TTL.
+	assert.NoError(t, tiered.Set(ctx, "b", []byte("3"), time.Hour))
+	tiered.Subscribed(false)
+	assert.Equal(t, 0, l1.Len())
+	assert.NoError(t, tiered.Set(ctx, "b", []byte("3"), time.Hour))
+	now = now.Add(5 * time.Second)
+	_, err = l1.Get(ctx, "b")
+	assert.Equal(t, ErrMiss, err)
+	value, err = tiered.Get(ctx, "b")
+	assert.NoError(t, err, "the entry is still in L2")
+	assert.Equal(t, []byte("3"), value)
+
+	assert.NoError(t, tiered.Delete(ctx, "b"))
+	_, err = l2.Get(ctx, "b")
+	assert.Equal(t, ErrMiss, err)
+	_, err = tiered.Get(ctx, "b")
+	assert.Equal(t, ErrMiss, err)
+}
+
+// blockingCache is an LRU whose Get, once read, waits for release.
+type blockingCache struct {
+	*LRU
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.LRU.Get(ctx, key)
+	c.read <- struct{}{}
+	<-c.release
+	return value, err
+}
+
+func TestTieredGetRacingInvalidation(t *testing.T) {
+	ctx := context.Background()
+	for name, invalidate := range map[string]func(tiered *Tiered){
+		"delete": func(tiered *Tiered) { assert.NoError(t, tiered.Delete(ctx, "a")) },
+		"evict":  func(tiered *Tiered) { tiered.Evict("a") },
+		"set":    func(tiered *Tiered) { assert.NoError(t, tiered.Set(ctx, "a", []byte("2"), time.Hour)) },
+		"purge":  func(tiered *Tiered) { tiered.Subscribed(false) },
+	} {
+		l1 := NewLRU(10)
+		l2 := &blockingCache{LRU: NewLRU(10), read: make(chan struct{}), release: make(chan struct{})}
+		tiered := NewTiered(l1, l2, TieredOptions{TTL: time.Minute, FallbackTTL: time.Minute})
+		tiered.Subscribed(true)
+		assert.NoError(t, l2.LRU.Set(ctx, "a", []byte("1"), time.Hour))
+
+		done := make(chan []byte)
+		go func() {
+			value, _ := tiered.Get(ctx, "a")
+			done <- value
+		}()
+
+		// The value is read from L2, then invalidated before it reaches L1.
+		<-l2.read
+		invalidate(tiered)
+		close(l2.release)
+		assert.Equal(t, []byte("1"), <-done, name)
+
+		value, err := l1.Get(ctx, "a")
+		if name == "set" {
+			assert.Equal(t, []byte("2"), value, name)
+		} else {
+			assert.Equal(t, ErrMiss, err, "%s: the stale value must not be put back into L1", name)
+		}
+	}
+}
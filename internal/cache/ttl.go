@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Jitter spreads ttl randomly by up to fraction of it in either direction, so
+// that entries written together do not all expire at the same moment.
+func Jitter(ttl time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || ttl <= 0 {
+		return ttl
+	}
+	spread := float64(ttl) * fraction
+	return ttl + time.Duration((rand.Float64()*2-1)*spread)
+}
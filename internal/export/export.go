@@ -0,0 +1,287 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/sheet"
+)
+
+const (
+	FormatCSV    = sheet.FormatCSV
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = sheet.FormatXLSX
+)
+
+// flushEvery is the number of rows between flushes of streamed output.
+const flushEvery = 1000
+
+// column reads one exported field from a person.
+type column func(p *models.Person) interface{}
+
+// columns lists the exportable fields.
+var columns = map[string]column{
+	"id":             func(p *models.Person) interface{} { return p.ID },
+	"name":           func(p *models.Person) interface{} { return p.Name },
+	"last_name":      func(p *models.Person) interface{} { return p.LastName },
+	"first_name":     func(p *models.Person) interface{} { return p.FirstName },
+	"middle_name":    func(p *models.Person) interface{} { return p.MiddleName },
+	"iin":            func(p *models.Person) interface{} { return p.IIN },
+	"phone":          func(p *models.Person) interface{} { return p.Phone },
+	"phone_operator": func(p *models.Person) interface{} { return p.PhoneOperator },
+	"phone_type":     func(p *models.Person) interface{} { return p.PhoneType },
+	"birth_date":     func(p *models.Person) interface{} { return p.BirthDate },
+	"sex":            func(p *models.Person) interface{} { return p.Sex },
+	"created_at":     func(p *models.Person) interface{} { return p.CreatedAt },
+	"deleted_at":     func(p *models.Person) interface{} { return p.DeletedAt },
+}
+
+// DefaultColumns are exported when no columns are requested.
+var DefaultColumns = []string{"id", "name", "iin", "phone", "birth_date", "sex", "created_at"}
+
+// maskers hide most of a sensitive value.
+var maskers = map[string]func(string) string{
+	"iin":   MaskIIN,
+	"phone": MaskPhone,
+}
+
+// DefaultMask masks every column that can be masked. MaskNone, given as the
+// mask, asks for none.
+var DefaultMask = []string{"iin", "phone"}
+
+const MaskNone = "none"
+
+// Options configures an export. Mask lists the columns to mask.
+type Options struct {
+	Format  string
+	Columns []string
+	Mask    []string
+}
+
+// ParseList splits a comma-separated list, dropping blanks.
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Validate checks the format, columns and masks, filling in the default
+// columns when none are given.
+func (o *Options) Validate() error {
+	switch o.Format {
+	case FormatCSV, FormatNDJSON, FormatXLSX:
+	default:
+		return fmt.Errorf("unsupported format %q, expected csv, ndjson or xlsx", o.Format)
+	}
+
+	if len(o.Columns) == 0 {
+		o.Columns = DefaultColumns
+	}
+	for _, name := range o.Columns {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("unknown column %q", name)
+		}
+	}
+	for _, name := range o.Mask {
+		if _, ok := maskers[name]; !ok {
+			return fmt.Errorf("column %q cannot be masked, expected iin or phone", name)
+		}
+	}
+	return nil
+}
+
+// ContentType returns the MIME type of an export format.
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return sheet.ContentType(format)
+}
+
+// Unmasked returns the exported columns that could be masked but are not.
+// Call it after Validate.
+func (o *Options) Unmasked() []string {
+	masked := map[string]bool{}
+	for _, name := range o.Mask {
+		masked[name] = true
+	}
+
+	var unmasked []string
+	for _, name := range o.Columns {
+		if _, ok := maskers[name]; ok && !masked[name] {
+			unmasked = append(unmasked, name)
+		}
+	}
+	return unmasked
+}
+
+// MaskIIN keeps the first four and the last two digits of an IIN.
+func MaskIIN(iin string) string {
+	return mask(iin, 4, 2)
+}
+
+// MaskPhone keeps the country and operator code and the last two digits of
+// an E.164 phone.
+func MaskPhone(phone string) string {
+	return mask(phone, 5, 2)
+}
+
+func mask(value string, head, tail int) string {
+	if len(value) <= head+tail {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:head] + strings.Repeat("*", len(value)-head-tail) + value[len(value)-tail:]
+}
+
+// Writer streams people in the chosen format. It implements
+// models.PersonSink: Begin writes the header and Write one row.
+type Writer struct {
+	opts    Options
+	out     io.Writer
+	buf     *bufio.Writer
+	sheet   sheet.Writer
+	masked  map[string]bool
+	written int
+}
+
+// NewWriter returns a writer producing opts.Format on w. Options must have
+// been validated. Streamed formats are flushed periodically, including to
+// w itself when it is an http.Flusher.
+func NewWriter(w io.Writer, opts Options) (*Writer, error) {
+	writer := &Writer{opts: opts, out: w, masked: map[string]bool{}}
+	for _, name := range opts.Mask {
+		writer.masked[name] = true
+	}
+
+	if opts.Format == FormatNDJSON {
+		writer.buf = bufio.NewWriter(w)
+		return writer, nil
+	}
+
+	s, err := sheet.NewWriter(w, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	writer.sheet = s
+	return writer, nil
+}
+
+func (w *Writer) Begin() error {
+	if w.sheet != nil {
+		return w.sheet.Write(w.opts.Columns)
+	}
+	return nil
+}
+
+func (w *Writer) Write(person *models.Person) error {
+	var err error
+	if w.sheet != nil {
+		err = w.sheet.Write(w.record(person))
+	} else {
+		err = w.writeJSON(person)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.written++
+	if w.written%flushEvery == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close flushes the remaining output. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.sheet != nil {
+		return w.sheet.Close()
+	}
+	return w.buf.Flush()
+}
+
+func (w *Writer) flush() error {
+	if w.opts.Format == FormatXLSX {
+		return nil
+	}
+	if w.buf != nil {
+		if err := w.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := w.sheet.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.out.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func (w *Writer) value(person *models.Person, name string) interface{} {
+	value := columns[name](person)
+	if w.masked[name] {
+		value = maskers[name](value.(string))
+	}
+	return value
+}
+
+func (w *Writer) record(person *models.Person) []string {
+	record := make([]string, len(w.opts.Columns))
+	for i, name := range w.opts.Columns {
+		switch value := w.value(person, name).(type) {
+		case string:
+			record[i] = escapeFormula(value)
+		case int:
+			record[i] = strconv.Itoa(value)
+		case *time.Time:
+			if value != nil {
+				record[i] = value.Format(time.RFC3339)
+			}
+		}
+	}
+	return record
+}
+
+// formulaPrefixes are the leading characters that make a spreadsheet treat a
+// cell as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula keeps a value from being evaluated when the file is opened in
+// a spreadsheet, by prefixing it with an apostrophe as Excel itself does.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// writeJSON writes one NDJSON line with the columns in the requested order.
+func (w *Writer) writeJSON(person *models.Person) error {
+	w.buf.WriteByte('{')
+	for i, name := range w.opts.Columns {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(w.value(person, name))
+		if err != nil {
+			return err
+		}
+		w.buf.Write(key)
+		w.buf.WriteByte(':')
+		w.buf.Write(value)
+	}
+	w.buf.WriteString("}\n")
+	return nil
+}
@@ -0,0 +1,88 @@
+package export_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/export"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/sheet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "0203******83", export.MaskIIN("020304550283"))
+	assert.Equal(t, "+7701*****67", export.MaskPhone("+77011234567"))
+	assert.Equal(t, "***", export.MaskIIN("123"))
+}
+
+func TestOptionsValidate(t *testing.T) {
+	opts := export.Options{Format: export.FormatNDJSON}
+	assert.NoError(t, opts.Validate())
+	assert.Equal(t, export.DefaultColumns, opts.Columns)
+
+	for _, bad := range []export.Options{
+		{Format: "xml"},
+		{Format: export.FormatCSV, Columns: []string{"name", "deleted_by"}},
+		{Format: export.FormatCSV, Mask: []string{"name"}},
+	} {
+		assert.Error(t, bad.Validate())
+	}
+}
+
+func TestWriter(t *testing.T) {
+	created := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	person := &models.Person{ID: 7, Name: "Dulat, Nurmeden", IIN: "020304550283", Phone: "+77011234567", CreatedAt: &created}
+
+	tests := map[string]string{
+		export.FormatCSV:    "id,name,iin,phone,created_at\n7,\"Dulat, Nurmeden\",0203******83,'+77011234567,2024-05-01T10:00:00Z\n",
+		export.FormatNDJSON: `{"id":7,"name":"Dulat, Nurmeden","iin":"0203******83","phone":"+77011234567","created_at":"2024-05-01T10:00:00Z"}` + "\n",
+	}
+	for format, expected := range tests {
+		opts := export.Options{Format: format, Columns: []string{"id", "name", "iin", "phone", "created_at"}, Mask: []string{"iin"}}
+		assert.NoError(t, opts.Validate())
+
+		var buf bytes.Buffer
+		w, err := export.NewWriter(&buf, opts)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Begin())
+		assert.NoError(t, w.Write(person))
+		assert.NoError(t, w.Close())
+		assert.Equal(t, expected, buf.String(), format)
+	}
+}
+
+func TestWriterEscapesFormulas(t *testing.T) {
+	person := &models.Person{ID: 7, Name: "=HYPERLINK(\"http://evil\")", LastName: "@SUM(A1)", Phone: "+77011234567", MiddleName: "-1+1", FirstName: "\rx"}
+	columns := []string{"id", "name", "last_name", "phone", "middle_name", "first_name"}
+	expected := []string{"7", `'=HYPERLINK("http://evil")`, "'@SUM(A1)", "'+77011234567", "'-1+1", "'\rx"}
+
+	for _, format := range []string{export.FormatCSV, export.FormatXLSX} {
+		opts := export.Options{Format: format, Columns: columns}
+		assert.NoError(t, opts.Validate())
+
+		var buf bytes.Buffer
+		w, err := export.NewWriter(&buf, opts)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Begin())
+		assert.NoError(t, w.Write(person))
+		assert.NoError(t, w.Close())
+
+		reader, err := sheet.NewReader(&buf, format)
+		assert.NoError(t, err)
+		var rows [][]string
+		for {
+			row, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			rows = append(rows, row)
+		}
+		if assert.Len(t, rows, 2, format) {
+			assert.Equal(t, expected, rows[1], format)
+		}
+	}
+}
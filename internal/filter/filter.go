@@ -0,0 +1,203 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	OpAnd = "AND"
+	OpOr  = "OR"
+)
+
+// Expr is a parsed filter expression. A condition has a Field and Value and
+// no Op; a group combines its Terms with OpAnd or OpOr.
+type Expr struct {
+	Op    string
+	Terms []Expr
+	Field string
+	Value string
+}
+
+// Parse reads a filter expression such as
+//
+//	phone_prefix:+7701 or (iin_prefix:0203 and created_between:2024-01-01..2024-12-31)
+//
+// Conditions are field:value pairs; values containing spaces or parentheses
+// are written in double quotes. "and" binds tighter than "or", keywords are
+// case-insensitive and parentheses group. Parse checks only the syntax: which
+// fields exist and what their values mean is up to the caller.
+func Parse(input string) (Expr, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return Expr{}, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return Expr{}, err
+	}
+	if p.pos < len(p.tokens) {
+		return Expr{}, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenCondition tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	field string
+	value string
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+		default:
+			start := i
+			for i < len(runes) && runes[i] != ':' && runes[i] != '(' && runes[i] != ')' && !unicode.IsSpace(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+
+			if i == len(runes) || runes[i] != ':' {
+				switch strings.ToUpper(word) {
+				case OpAnd:
+					tokens = append(tokens, token{kind: tokenAnd, text: word})
+				case OpOr:
+					tokens = append(tokens, token{kind: tokenOr, text: word})
+				default:
+					return nil, fmt.Errorf("expected field:value, got %q", word)
+				}
+				continue
+			}
+			if word == "" {
+				return nil, fmt.Errorf("missing field name before ':'")
+			}
+
+			i++
+			value, next, err := readValue(runes, i)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", word, err)
+			}
+			tokens = append(tokens, token{kind: tokenCondition, text: string(runes[start:next]), field: word, value: value})
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// readValue reads a bare or double-quoted value starting at i and returns it
+// with the position following it.
+func readValue(runes []rune, i int) (string, int, error) {
+	if i < len(runes) && runes[i] == '"' {
+		var value strings.Builder
+		for i++; i < len(runes); i++ {
+			switch runes[i] {
+			case '\\':
+				if i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			case '"':
+				return value.String(), i + 1, nil
+			default:
+				value.WriteRune(runes[i])
+			}
+		}
+		return "", i, fmt.Errorf("unterminated quoted value")
+	}
+
+	start := i
+	for i < len(runes) && runes[i] != '(' && runes[i] != ')' && !unicode.IsSpace(runes[i]) {
+		i++
+	}
+	if start == i {
+		return "", i, fmt.Errorf("missing value")
+	}
+	return string(runes[start:i]), i, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	return p.parseGroup(OpOr, tokenOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	return p.parseGroup(OpAnd, tokenAnd, p.parseFactor)
+}
+
+// parseGroup parses operands separated by the operator token, flattening a
+// single operand to itself.
+func (p *parser) parseGroup(op string, kind tokenKind, operand func() (Expr, error)) (Expr, error) {
+	first, err := operand()
+	if err != nil {
+		return Expr{}, err
+	}
+
+	terms := []Expr{first}
+	for p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return Expr{}, err
+		}
+		terms = append(terms, next)
+	}
+
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return Expr{Op: op, Terms: terms}, nil
+}
+
+func (p *parser) parseFactor() (Expr, error) {
+	if p.pos == len(p.tokens) {
+		return Expr{}, fmt.Errorf("unexpected end of filter")
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokenCondition:
+		return Expr{Field: tok.field, Value: tok.value}, nil
+	case tokenOpen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return Expr{}, err
+		}
+		if p.pos == len(p.tokens) || p.tokens[p.pos].kind != tokenClose {
+			return Expr{}, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return expr, nil
+	default:
+		return Expr{}, fmt.Errorf("unexpected %q", tok.text)
+	}
+}
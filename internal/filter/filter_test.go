@@ -0,0 +1,52 @@
+package filter_test
+
+import (
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	expr, err := filter.Parse(`phone_prefix:+7701 OR (iin_prefix:0203 and created_between:2024-01-01..2024-12-31)`)
+	assert.NoError(t, err)
+	assert.Equal(t, filter.Expr{Op: filter.OpOr, Terms: []filter.Expr{
+		{Field: "phone_prefix", Value: "+7701"},
+		{Op: filter.OpAnd, Terms: []filter.Expr{
+			{Field: "iin_prefix", Value: "0203"},
+			{Field: "created_between", Value: "2024-01-01..2024-12-31"},
+		}},
+	}}, expr)
+
+	expr, err = filter.Parse(`iin_prefix:02 and phone_prefix:"+7 (701)" or iin_prefix:03`)
+	assert.NoError(t, err)
+	assert.Equal(t, filter.Expr{Op: filter.OpOr, Terms: []filter.Expr{
+		{Op: filter.OpAnd, Terms: []filter.Expr{
+			{Field: "iin_prefix", Value: "02"},
+			{Field: "phone_prefix", Value: "+7 (701)"},
+		}},
+		{Field: "iin_prefix", Value: "03"},
+	}}, expr)
+
+	expr, err = filter.Parse(`((iin_prefix:02))`)
+	assert.NoError(t, err)
+	assert.Equal(t, filter.Expr{Field: "iin_prefix", Value: "02"}, expr)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`iin_prefix`,
+		`iin_prefix:`,
+		`:02`,
+		`iin_prefix:02 and`,
+		`iin_prefix:02 iin_prefix:03`,
+		`(iin_prefix:02`,
+		`iin_prefix:02)`,
+		`phone_prefix:"+7701`,
+		`or iin_prefix:02`,
+	} {
+		_, err := filter.Parse(input)
+		assert.Error(t, err, input)
+	}
+}
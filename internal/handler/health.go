@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// readinessTimeout bounds the database check of a readiness probe.
+const readinessTimeout = 2 * time.Second
+
+// DBPinger is satisfied by *sql.DB.
+type DBPinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthHandler serves liveness, readiness and cache health. The cache is
+// reported on its own: the service keeps serving from the database while it
+// is down, so it does not make the instance unready.
+type HealthHandler struct {
+	db           DBPinger
+	cacheBackend string
+	breaker      *cache.Breaker
+	tiered       *cache.Tiered
+	Logger       *logrus.Logger
+}
+
+// NewHealthHandler returns a handler for the given database and cache
+// backend; breaker is nil for backends that cannot fail, and tiered unless an
+// L1 is in front of the backend.
+func NewHealthHandler(db DBPinger, cacheBackend string, breaker *cache.Breaker, tiered *cache.Tiered, logger *logrus.Logger) *HealthHandler {
+	return &HealthHandler{db: db, cacheBackend: cacheBackend, breaker: breaker, tiered: tiered, Logger: logger}
+}
+
+// Live godoc
+// @Summary     Liveness probe
+// @Tags        Health
+// @Produce     json
+// @Success     200  {object}  map[string]string
+// @Router      /health/live [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready godoc
+// @Summary     Readiness probe
+// @Description Ready when the database answers; the cache is not considered, see /health/cache
+// @Tags        Health
+// @Produce     json
+// @Success     200  {object}  map[string]string
+// @Failure     503  {object}  map[string]string
+// @Router      /health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		h.Logger.WithError(err).Warn("Readiness check failed")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "database": "down"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "database": "up"})
+}
+
+// Cache godoc
+// @Summary     Cache health
+// @Description Reports the cache backend, for Redis its circuit breaker, and with an L1 the hits and misses of each tier. Responds 503 while the breaker is not closed.
+// @Tags        Health
+// @Produce     json
+// @Success     200  {object}  map[string]interface{}
+// @Failure     503  {object}  map[string]interface{}
+// @Router      /health/cache [get]
+func (h *HealthHandler) Cache(c *gin.Context) {
+	body := gin.H{"backend": h.cacheBackend, "status": "up"}
+	if h.cacheBackend == "none" {
+		body["status"] = "disabled"
+	}
+	if h.tiered != nil {
+		body["tiers"] = h.tiered.Stats()
+	}
+	if h.breaker == nil {
+		c.JSON(http.StatusOK, body)
+		return
+	}
+
+	health := h.breaker.Health()
+	body["breaker"] = health
+	switch health.State {
+	case cache.StateClosed:
+		c.JSON(http.StatusOK, body)
+		return
+	case cache.StateHalfOpen:
+		body["status"] = "probing"
+	default:
+		body["status"] = "down"
+	}
+	c.JSON(http.StatusServiceUnavailable, body)
+}
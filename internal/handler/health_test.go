@@ -0,0 +1,66 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/handler"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDB struct {
+	err error
+}
+
+func (db fakeDB) PingContext(ctx context.Context) error {
+	return db.err
+}
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	breaker := cache.NewBreaker(cache.Noop{}, cache.BreakerOptions{Threshold: 1, Cooldown: 0}, logger)
+
+	get := func(h *handler.HealthHandler, path string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/health/ready", h.Ready)
+		router.GET("/health/cache", h.Cache)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	h := handler.NewHealthHandler(fakeDB{}, "redis", breaker, nil, logger)
+	assert.Equal(t, http.StatusOK, get(h, "/health/ready").Code)
+	w := get(h, "/health/cache")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"backend":"redis","status":"up","breaker":{"state":"closed","failures":0}}`, w.Body.String())
+
+	// The cache being down is reported on its own and leaves the instance ready.
+	breaker.Open(errors.New("connection refused"))
+	assert.Equal(t, http.StatusOK, get(h, "/health/ready").Code)
+	w = get(h, "/health/cache")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"down"`)
+	assert.Contains(t, w.Body.String(), `"last_error":"connection refused"`)
+
+	h = handler.NewHealthHandler(fakeDB{err: errors.New("no database")}, "none", nil, nil, logger)
+	assert.Equal(t, http.StatusServiceUnavailable, get(h, "/health/ready").Code)
+	w = get(h, "/health/cache")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"backend":"none","status":"disabled"}`, w.Body.String())
+
+	tiered := cache.NewTiered(cache.NewLRU(10), cache.Noop{}, cache.TieredOptions{TTL: time.Minute})
+	tiered.Get(context.Background(), "key")
+	h = handler.NewHealthHandler(fakeDB{}, "redis", nil, tiered, logger)
+	w = get(h, "/health/cache")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"backend":"redis","status":"up","tiers":{"l1":{"hits":0,"misses":1},"l2":{"hits":0,"misses":1},"l1_entries":0,"subscribed":false}}`, w.Body.String())
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ddProgerGo/task-kaspi/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CheckBIN godoc
+// @Summary     Validate BIN
+// @Description Checks if the provided business identification number is valid
+// @Tags        BIN
+// @Accept      json
+// @Produce     json
+// @Param       bin  path  string  true  "BIN number"
+// @Success     200  {object}  utils.BINInfo
+// @Failure     400  {object}  map[string]string
+// @Router      /bin_check/{bin} [get]
+func (h *PersonHandler) CheckBIN(c *gin.Context) {
+	bin := c.Param("bin")
+
+	info, err := utils.ValidateBIN(bin)
+	if err != nil {
+		h.Logger.WithError(err).Warn("Invalid BIN check")
+		c.Error(err)
+		return
+	}
+
+	h.Logger.Info("BIN validation successful: ", bin)
+	c.JSON(http.StatusOK, info)
+}
+
+// CheckID godoc
+// @Summary     Validate IIN or BIN
+// @Description Detects whether the number is an IIN or a BIN and validates it
+// @Tags        IIN
+// @Accept      json
+// @Produce     json
+// @Param       number  path  string  true  "IIN or BIN number"
+// @Success     200  {object}  utils.IDInfo
+// @Failure     400  {object}  map[string]string
+// @Router      /id_check/{number} [get]
+func (h *PersonHandler) CheckID(c *gin.Context) {
+	number := c.Param("number")
+
+	info, err := utils.ValidateID(number)
+	if err != nil {
+		h.Logger.WithError(err).Warn("Invalid ID check")
+		c.Error(err)
+		return
+	}
+
+	h.Logger.Info("ID validation successful: ", number)
+	c.JSON(http.StatusOK, info)
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ddProgerGo/task-kaspi/internal/utils"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	batchFlushEvery   = 100
+	maxBatchLineSize  = 1024
+)
+
+type IINBatchItem struct {
+	Index  int             `json:"index"`
+	IIN    string          `json:"iin"`
+	Result *utils.IINInfo  `json:"result,omitempty"`
+	Error  *IINBatchReason `json:"error,omitempty"`
+}
+
+type IINBatchReason struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// iinSource yields IINs one by one from the request body and returns io.EOF when exhausted.
+type iinSource func() (string, error)
+
+// BatchCheckIIN godoc
+// @Summary     Validate a batch of IINs
+// @Description Validates IINs given as a JSON array of strings or as newline-delimited text.
+// @Description Results are streamed per item as a JSON array, or as NDJSON when Accept is application/x-ndjson.
+// @Tags        IIN
+// @Accept      json,plain
+// @Produce     json,application/x-ndjson
+// @Param       iins  body  []string  true  "IINs to validate"
+// @Success     200  {array}   handler.IINBatchItem
+// @Failure     400  {object}  map[string]string
+// @Router      /iin_check/batch [post]
+func (h *PersonHandler) BatchCheckIIN(c *gin.Context) {
+	next, err := newIINSource(c.Request)
+	if err != nil {
+		h.Logger.WithError(err).Warn("Invalid IIN batch body")
+		c.Error(errors.ErrBadRequest)
+		return
+	}
+
+	ndjson := strings.Contains(c.GetHeader("Accept"), ndjsonContentType)
+	if ndjson {
+		c.Header("Content-Type", ndjsonContentType)
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	if !ndjson {
+		c.Writer.WriteString("[")
+	}
+
+	count := 0
+	for {
+		iin, err := next()
+		if err == io.EOF {
+			break
+		}
+
+		item := IINBatchItem{Index: count, IIN: iin}
+		malformed := err != nil
+		if malformed {
+			h.Logger.WithError(err).Warn("Malformed IIN batch item")
+			item.Error = &IINBatchReason{Reason: "malformed_input", Message: err.Error()}
+		} else if info, err := utils.ValidateIIN(iin); err != nil {
+			item.Error = &IINBatchReason{Reason: utils.IINErrorReason(err), Message: err.Error()}
+		} else {
+			item.Result = info
+		}
+
+		if !ndjson && count > 0 {
+			c.Writer.WriteString(",")
+		}
+		if err := encoder.Encode(item); err != nil {
+			h.Logger.WithError(err).Error("Failed to write IIN batch item")
+			return
+		}
+
+		count++
+		if count%batchFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+
+		if malformed {
+			break
+		}
+	}
+
+	if !ndjson {
+		c.Writer.WriteString("]")
+	}
+	c.Writer.Flush()
+
+	h.Logger.Info("IIN batch validation finished: ", count)
+}
+
+func newIINSource(r *http.Request) (iinSource, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return newJSONArraySource(r.Body)
+	}
+	return newLineSource(r.Body), nil
+}
+
+func newJSONArraySource(body io.Reader) (iinSource, error) {
+	decoder := json.NewDecoder(body)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.ErrBadRequest
+	}
+
+	done := false
+	return func() (string, error) {
+		if done {
+			return "", io.EOF
+		}
+		if !decoder.More() {
+			// A truncated body ends without the closing bracket and must
+			// not pass for a complete batch.
+			done = true
+			token, err := decoder.Token()
+			if delim, ok := token.(json.Delim); err != nil || !ok || delim != ']' {
+				return "", fmt.Errorf("JSON array is not terminated with ]")
+			}
+			return "", io.EOF
+		}
+
+		var iin string
+		if err := decoder.Decode(&iin); err != nil {
+			done = true
+			return "", err
+		}
+		return iin, nil
+	}, nil
+}
+
+func newLineSource(body io.Reader) iinSource {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, maxBatchLineSize), maxBatchLineSize)
+
+	return func() (string, error) {
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			return strings.Trim(line, `"`), nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/export"
+	"github.com/ddProgerGo/task-kaspi/internal/middleware"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportPeople godoc
+// @Summary     Export people
+// @Description Streams all people matching the filters as CSV, NDJSON or XLSX, read through a server-side cursor.
+// @Description IINs and phones are masked unless the mask parameter says otherwise; only admins may export them unmasked.
+// @Tags        Person
+// @Produce     text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param       format   query  string  false  "Export format"  Enums(csv, ndjson, xlsx) default(csv)
+// @Param       columns  query  string  false  "Comma-separated columns" default(id,name,iin,phone,birth_date,sex,created_at)
+// @Param       mask     query  string  false  "Comma-separated columns to mask, or none (admin only for unmasked iin or phone)" default(iin,phone)
+// @Param       include_deleted  query  bool  false  "Include soft-deleted people (admin only)"
+// @Param       sort     query  string  false  "Comma-separated sort fields, minus for descending; by id when empty"
+// @Param       filter   query  string  false  "Filter expression, e.g. phone_prefix:+7701 or iin_prefix:0203"
+// @Param       sex          query  string  false  "Filter by sex"  Enums(male, female)
+// @Param       born_after   query  string  false  "Born after date (YYYY-MM-DD)"
+// @Param       born_before  query  string  false  "Born before date (YYYY-MM-DD)"
+// @Param       min_age      query  int     false  "Minimum age in full years"
+// @Param       max_age      query  int     false  "Maximum age in full years"
+// @Success     200  {file}    file
+// @Failure     400  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /people/export [get]
+func (h *PersonHandler) ExportPeople(c *gin.Context) {
+	opts := export.Options{
+		Format:  c.DefaultQuery("format", export.FormatCSV),
+		Columns: export.ParseList(c.Query("columns")),
+		Mask:    export.DefaultMask,
+	}
+	if value, ok := c.GetQuery("mask"); ok {
+		opts.Mask = nil
+		if value != export.MaskNone {
+			opts.Mask = export.ParseList(value)
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		h.Logger.WithError(err).Warn("Invalid export options")
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": err.Error()})
+		return
+	}
+
+	// The export has no other access control, so raw IINs and phones are
+	// for admins only.
+	if unmasked := opts.Unmasked(); len(unmasked) > 0 && !middleware.IsAdmin(c) {
+		h.Logger.Warn("Unmasked export requested without admin privileges: ", unmasked)
+		c.Error(&errors.AppError{Code: errors.ErrForbidden.Code, Message: errors.ErrForbidden.Message, IsDefault: true, Field: "mask"})
+		return
+	}
+
+	includeDeleted, ok := h.includeDeleted(c)
+	if !ok {
+		return
+	}
+
+	query := models.PeopleQuery{IncludeDeleted: includeDeleted}
+	if msg := bindPeopleFilters(c, &query); msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+	if msg := h.bindListing(c, &query); msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+
+	w, err := export.NewWriter(c.Writer, opts)
+	if err != nil {
+		h.Logger.WithError(err).Error("Failed to create export writer")
+		h.handleServiceError(c, err)
+		return
+	}
+
+	sink := &httpExportSink{Writer: w, c: c, format: opts.Format}
+	if err := h.service.ExportPeople(c.Request.Context(), query, sink); err != nil {
+		if !sink.started {
+			h.handleServiceError(c, err)
+			return
+		}
+		// The status line is gone; the truncated body is all the client gets.
+		h.Logger.WithError(err).Error("People export aborted")
+		return
+	}
+
+	if err := w.Close(); err != nil {
+		h.Logger.WithError(err).Error("Failed to finish people export")
+	}
+}
+
+// httpExportSink sends the response headers once the export query has been
+// accepted, so that invalid filters can still be answered with 400. It stops
+// the export when the client goes away.
+type httpExportSink struct {
+	*export.Writer
+	c       *gin.Context
+	format  string
+	started bool
+}
+
+func (s *httpExportSink) Begin() error {
+	s.started = true
+	s.c.Header("Content-Type", export.ContentType(s.format))
+	s.c.Header("Content-Disposition", `attachment; filename="people-`+time.Now().Format("20060102-150405")+`.`+s.format+`"`)
+	s.c.Status(http.StatusOK)
+	return s.Writer.Begin()
+}
+
+func (s *httpExportSink) Write(person *models.Person) error {
+	if err := s.c.Request.Context().Err(); err != nil {
+		return err
+	}
+	return s.Writer.Write(person)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/sheet"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize bounds the uploaded spreadsheet.
+const maxImportSize = 32 << 20
+
+// ImportPeople godoc
+// @Summary     Import people from CSV or XLSX
+// @Description Imports people from a spreadsheet with a header row (name or last_name/first_name/middle_name, iin, phone).
+// @Description The file is sent as the multipart field "file" or as the raw body with a text/csv or XLSX content type.
+// @Description Every row is validated; valid rows are inserted in batches and the response reports each row as accepted, duplicate or rejected.
+// @Description When a batch fails after earlier ones were written, the error response still carries the report in "data", with the unwritten rows marked not_processed.
+// @Tags        Person
+// @Accept      mpfd,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce     json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param       file     formData  file    false  "Spreadsheet to import"
+// @Param       format   query     string  false  "Input format when it cannot be inferred"  Enums(csv, xlsx)
+// @Param       dry_run  query     bool    false  "Validate and report without writing"  default(false)
+// @Param       report   query     string  false  "Download the report as a spreadsheet instead of JSON"  Enums(json, csv, xlsx) default(json)
+// @Success     200  {object}  models.ImportReport
+// @Failure     400  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /people/import [post]
+func (h *PersonHandler) ImportPeople(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		h.Logger.Warn("Invalid dry_run flag")
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": "Invalid dry_run, expected true or false"})
+		return
+	}
+
+	reportFormat := c.DefaultQuery("report", "json")
+	if reportFormat != "json" && reportFormat != sheet.FormatCSV && reportFormat != sheet.FormatXLSX {
+		h.Logger.Warn("Invalid report format")
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": "Invalid report, expected json, csv or xlsx"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	body, format, err := importSource(c)
+	if err != nil {
+		h.Logger.WithError(err).Warn("Invalid import upload")
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	rows, err := sheet.NewReader(body, format)
+	if err != nil {
+		h.Logger.WithError(err).Warn("Unreadable import file")
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": err.Error()})
+		return
+	}
+
+	report, err := h.service.ImportPeople(c.Request.Context(), rows, dryRun)
+	if err != nil {
+		h.Logger.WithError(err).Error("Error importing people")
+		if report == nil {
+			h.handleServiceError(c, err)
+			return
+		}
+
+		// Some batches were written before the failure: the report tells
+		// the client which rows, whatever format was asked for.
+		status := http.StatusInternalServerError
+		if appErr, ok := err.(*errors.AppError); ok {
+			status = appErr.Code
+		}
+		c.JSON(status, gin.H{"success": false, "error": report.Error, "data": report})
+		return
+	}
+
+	if reportFormat == "json" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": report})
+		return
+	}
+
+	c.Header("Content-Type", sheet.ContentType(reportFormat))
+	c.Header("Content-Disposition", `attachment; filename="import-report-`+time.Now().Format("20060102-150405")+`.`+reportFormat+`"`)
+	c.Status(http.StatusOK)
+
+	w, err := sheet.NewWriter(c.Writer, reportFormat)
+	if err != nil {
+		h.Logger.WithError(err).Error("Failed to create import report writer")
+		return
+	}
+	for _, record := range report.Records() {
+		if err := w.Write(record); err != nil {
+			h.Logger.WithError(err).Error("Failed to write import report")
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		h.Logger.WithError(err).Error("Failed to write import report")
+	}
+}
+
+// importSource returns the uploaded spreadsheet and its format, taken from
+// the "format" query parameter or inferred from the file name or content type.
+func importSource(c *gin.Context) (io.ReadCloser, string, error) {
+	format := c.Query("format")
+
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		if format == "" {
+			format = sheet.DetectFormat(header.Filename, header.Header.Get("Content-Type"))
+		}
+		if format == "" {
+			file.Close()
+			return nil, "", fmt.Errorf("cannot tell the file format, use a .csv or .xlsx file or set format")
+		}
+		return file, format, nil
+	}
+
+	if format == "" {
+		format = sheet.DetectFormat("", c.ContentType())
+	}
+	if format == "" {
+		return nil, "", fmt.Errorf("send the file as multipart field \"file\" or as a text/csv or XLSX body")
+	}
+	return c.Request.Body, format, nil
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/filter"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const queryDateLayout = "2006-01-02"
+
+// MaxLimit caps the page size of people listings.
+const MaxLimit = 100
+
+// CacheStatusHeader tells whether a cached search page was served from the
+// cache (HIT) or loaded (MISS). Hits also carry an Age header.
+const CacheStatusHeader = "X-Cache"
+
+// bindPagination reads page and limit from the query string.
+// It returns a client-facing message when either is malformed.
+func bindPagination(c *gin.Context) (int, int, string) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, "Invalid page number"
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > MaxLimit {
+		return 0, 0, "Invalid limit number, expected 1 to " + strconv.Itoa(MaxLimit)
+	}
+
+	return page, limit, ""
+}
+
+// bindListing reads the listing options shared by all people listings: sort,
+// filter, include_total and the keyset cursor. A cursor replaces the page
+// number, so the two cannot be combined. Sort and filter fields are checked
+// against the repository whitelist later; here only the syntax is checked.
+// It returns a client-facing message when an option is malformed.
+func (h *PersonHandler) bindListing(c *gin.Context, q *models.PeopleQuery) string {
+	q.Sort = strings.ReplaceAll(c.Query("sort"), " ", "")
+
+	if value := c.Query("filter"); value != "" {
+		expr, err := filter.Parse(value)
+		if err != nil {
+			return "Invalid filter: " + err.Error()
+		}
+		q.Filter = &expr
+	}
+
+	includeTotal, err := strconv.ParseBool(c.DefaultQuery("include_total", "true"))
+	if err != nil {
+		return "Invalid include_total, expected true or false"
+	}
+	q.SkipTotal = !includeTotal
+
+	token := c.Query("cursor")
+	if token == "" {
+		return ""
+	}
+	if _, ok := c.GetQuery("page"); ok {
+		return "page cannot be combined with cursor"
+	}
+
+	if q.Cursor, err = h.cursors.Decode(token); err != nil {
+		return "Invalid cursor"
+	}
+	return ""
+}
+
+// writePeoplePage renders a page of a people listing. page is reported only
+// for offset paging and total only when it was counted; the cursors are null
+// at the ends of the listing.
+func (h *PersonHandler) writePeoplePage(c *gin.Context, q models.PeopleQuery, result *models.PeoplePage) {
+	people := result.People
+	if people == nil {
+		people = []models.Person{}
+	}
+
+	response := gin.H{
+		"success":     true,
+		"data":        people,
+		"limit":       q.Limit,
+		"next_cursor": nullableCursor(h.cursors.Encode(result.Next)),
+		"prev_cursor": nullableCursor(h.cursors.Encode(result.Prev)),
+	}
+	if q.Cursor == nil {
+		response["page"] = q.Page
+	}
+	if result.Total != nil {
+		response["total"] = *result.Total
+	}
+
+	if result.CacheStatus != "" {
+		c.Header(CacheStatusHeader, result.CacheStatus)
+		if result.CacheStatus == models.CacheHit {
+			c.Header("Age", strconv.Itoa(int(time.Since(result.CachedAt).Seconds())))
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func nullableCursor(token string) interface{} {
+	if token == "" {
+		return nil
+	}
+	return token
+}
+
+// bindPeopleFilters reads the optional people search filters from the query string.
+// It returns a client-facing message when a filter is malformed.
+func bindPeopleFilters(c *gin.Context, q *models.PeopleQuery) string {
+	q.Mode = c.DefaultQuery("mode", models.SearchModeContains)
+	switch q.Mode {
+	case models.SearchModeExact, models.SearchModePrefix, models.SearchModeContains:
+	case models.SearchModeFuzzy:
+		q.Threshold = models.DefaultSimilarityThreshold
+		if value := c.Query("threshold"); value != "" {
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil || threshold <= 0 || threshold > 1 {
+				return "Invalid threshold, expected a number in (0, 1]"
+			}
+			q.Threshold = threshold
+		}
+	default:
+		return "Invalid mode, expected exact, prefix, contains or fuzzy"
+	}
+
+	q.NamePart = c.Query("name_part")
+	switch q.NamePart {
+	case "", models.NamePartLast, models.NamePartFirst, models.NamePartMiddle:
+	default:
+		return "Invalid name_part, expected last_name, first_name or middle_name"
+	}
+
+	if sex := c.Query("sex"); sex != "" {
+		if sex != utils.SexMale && sex != utils.SexFemale {
+			return "Invalid sex, expected male or female"
+		}
+		q.Sex = sex
+	}
+
+	var ok bool
+	if q.BornAfter, ok = queryDate(c, "born_after"); !ok {
+		return "Invalid born_after date, expected YYYY-MM-DD"
+	}
+	if q.BornBefore, ok = queryDate(c, "born_before"); !ok {
+		return "Invalid born_before date, expected YYYY-MM-DD"
+	}
+	if q.MinAge, ok = queryAge(c, "min_age"); !ok {
+		return "Invalid min_age"
+	}
+	if q.MaxAge, ok = queryAge(c, "max_age"); !ok {
+		return "Invalid max_age"
+	}
+
+	if q.MinAge != nil && q.MaxAge != nil && *q.MinAge > *q.MaxAge {
+		return "min_age must not exceed max_age"
+	}
+
+	return ""
+}
+
+func queryDate(c *gin.Context, key string) (*time.Time, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, true
+	}
+
+	date, err := time.Parse(queryDateLayout, value)
+	if err != nil {
+		return nil, false
+	}
+	return &date, true
+}
+
+func queryAge(c *gin.Context, key string) (*int, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, true
+	}
+
+	age, err := strconv.Atoi(value)
+	if err != nil || age < 0 {
+		return nil, false
+	}
+	return &age, true
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/ddProgerGo/task-kaspi/internal/middleware"
 	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/pagination"
 	"github.com/ddProgerGo/task-kaspi/internal/service"
 	"github.com/ddProgerGo/task-kaspi/internal/utils"
 	"github.com/ddProgerGo/task-kaspi/pkg/errors"
@@ -12,13 +16,17 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ActorHeader identifies the user performing a modifying request.
+const ActorHeader = "X-User"
+
 type PersonHandler struct {
 	service service.PersonServiceInterface
+	cursors *pagination.Codec
 	Logger  *logrus.Logger
 }
 
-func NewPersonHandler(service service.PersonServiceInterface, logger *logrus.Logger) *PersonHandler {
-	return &PersonHandler{service: service, Logger: logger}
+func NewPersonHandler(service service.PersonServiceInterface, logger *logrus.Logger, cursors *pagination.Codec) *PersonHandler {
+	return &PersonHandler{service: service, cursors: cursors, Logger: logger}
 }
 
 // CheckIIN godoc
@@ -27,14 +35,26 @@ func NewPersonHandler(service service.PersonServiceInterface, logger *logrus.Log
 // @Tags        IIN
 // @Accept      json
 // @Produce     json
-// @Param       iin  path  string  true  "IIN number"
-// @Success     200  {object}  map[string]interface{}
+// @Param       iin    path   string  true   "IIN number"
+// @Param       as_of  query  string  false  "Date (YYYY-MM-DD) to compute age and adulthood at, defaults to today"
+// @Success     200  {object}  utils.IINInfo
 // @Failure     400  {object}  map[string]string
 // @Router      /check-iin/{iin} [get]
 func (h *PersonHandler) CheckIIN(c *gin.Context) {
 	iin := c.Param("iin")
 
-	info, err := utils.ValidateIIN(iin)
+	asOf := time.Now()
+	if value := c.Query("as_of"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			h.Logger.WithError(err).Warn("Invalid as_of date")
+			c.Error(errors.ErrBadRequest)
+			return
+		}
+		asOf = parsed
+	}
+
+	info, err := utils.ValidateIINAt(iin, asOf)
 	if err != nil {
 		h.Logger.WithError(err).Warn("Invalid IIN check")
 		c.Error(err)
@@ -47,16 +67,29 @@ func (h *PersonHandler) CheckIIN(c *gin.Context) {
 
 // SavePerson godoc
 // @Summary     Save a person
-// @Description Saves a new person to the database
+// @Description Saves a new person to the database. With an Idempotency-Key header a retried request replays the original response.
+// @Description A person whose IIN is already stored is rejected with 409 and the stored person's id, unless on_conflict asks to update or keep it.
 // @Tags        Person
 // @Accept      json
 // @Produce     json
-// @Param       person  body  models.Person  true  "Person data"
-// @Success     200  {object}  map[string]bool
+// @Param       person           body    models.Person  true   "Person data"
+// @Param       on_conflict      query   string         false  "What to do when the IIN is already stored" Enums(error, update, ignore) default(error)
+// @Param       Idempotency-Key  header  string         false  "Unique key making retries safe"
+// @Success     200  {object}  map[string]interface{}
 // @Failure     400  {object}  map[string]string
+// @Failure     409  {object}  map[string]interface{}
+// @Failure     422  {object}  map[string]string
 // @Failure     500  {object}  map[string]string
 // @Router      /save-person [post]
 func (h *PersonHandler) SavePerson(c *gin.Context) {
+	onConflict := c.DefaultQuery("on_conflict", models.OnConflictError)
+	switch onConflict {
+	case models.OnConflictError, models.OnConflictUpdate, models.OnConflictIgnore:
+	default:
+		c.Error(&errors.AppError{Code: http.StatusBadRequest, Message: "Invalid on_conflict, expected error, update or ignore", IsDefault: true, Field: "on_conflict"})
+		return
+	}
+
 	var person models.Person
 	if err := c.ShouldBindJSON(&person); err != nil {
 		h.Logger.WithError(err).Warn("Invalid request format")
@@ -64,19 +97,15 @@ func (h *PersonHandler) SavePerson(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.SavePerson(person); err != nil {
+	result, err := h.service.SavePerson(c.Request.Context(), person, onConflict)
+	if err != nil {
 		h.Logger.WithError(err).Error("Failed to save person")
-
-		if appErr, ok := err.(*errors.AppError); ok {
-			c.Error(&errors.AppError{Code: appErr.Code, Message: appErr.Message, IsDefault: true})
-		} else {
-			c.Error(&errors.AppError{Code: http.StatusInternalServerError, Message: err.Error(), IsDefault: true})
-		}
+		h.handleServiceError(c, err)
 		return
 	}
 
 	h.Logger.Info("Person saved successfully: ", person.IIN)
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
 }
 
 // GetPersonByIIN godoc
@@ -85,17 +114,24 @@ func (h *PersonHandler) SavePerson(c *gin.Context) {
 // @Tags        Person
 // @Accept      json
 // @Produce     json
-// @Param       iin  path  string  true  "IIN number"
+// @Param       iin              path   string  true   "IIN number"
+// @Param       include_deleted  query  bool    false  "Include soft-deleted people (admin only)"
 // @Success     200  {object}  models.Person
+// @Failure     403  {object}  map[string]string
 // @Failure     404  {object}  map[string]string
 // @Router      /get-person/{iin} [get]
 func (h *PersonHandler) GetPersonByIIN(c *gin.Context) {
 	iin := c.Param("iin")
 
-	person, err := h.service.GetPersonByIIN(iin)
+	includeDeleted, ok := h.includeDeleted(c)
+	if !ok {
+		return
+	}
+
+	person, err := h.service.GetPersonByIIN(c.Request.Context(), iin, includeDeleted)
 	if err != nil {
 		if appErr, ok := err.(*errors.AppError); ok {
-			c.Error(&errors.AppError{Code: appErr.Code, Message: appErr.Message, IsDefault: true})
+			c.Error(&errors.AppError{Code: appErr.Code, Message: appErr.Message, IsDefault: true, Field: appErr.Field})
 		} else {
 			c.Error(errors.ErrNotFound)
 		}
@@ -107,50 +143,336 @@ func (h *PersonHandler) GetPersonByIIN(c *gin.Context) {
 
 // GetPeopleByName godoc
 // @Summary     Get people by name with pagination
-// @Description Retrieves a paginated list of people matching the provided name
+// @Description Retrieves a paginated list of people matching the provided name, ordered by relevance with a score per hit
 // @Tags        Person
 // @Accept      json
 // @Produce     json
 // @Param       name   path      string  true  "Person name"
 // @Param       page   query     int     false "Page number" default(1)
-// @Param       limit  query     int     false "Results per page" default(10)
+// @Param       limit  query     int     false "Results per page (at most 100)" default(10)
+// @Param       include_deleted  query  bool  false  "Include soft-deleted people (admin only)"
+// @Param       cursor         query  string  false  "Opaque next_cursor or prev_cursor from a previous page; replaces page"
+// @Param       include_total  query  bool    false  "Count all matches for the total field" default(true)
+// @Param       sort           query  string  false  "Comma-separated sort fields, minus for descending, e.g. -created_at,name"
+// @Param       filter         query  string  false  "Filter expression, e.g. phone_prefix:+7701 or iin_prefix:0203"
+// @Param       mode         query  string  false  "Name matching mode"  Enums(exact, prefix, contains, fuzzy) default(contains)
+// @Param       threshold    query  number  false  "Minimum similarity for fuzzy mode" default(0.3)
+// @Param       name_part    query  string  false  "Match a single name part instead of the full name"  Enums(last_name, first_name, middle_name)
+// @Param       sex          query  string  false  "Filter by sex"  Enums(male, female)
+// @Param       born_after   query  string  false  "Born after date (YYYY-MM-DD)"
+// @Param       born_before  query  string  false  "Born before date (YYYY-MM-DD)"
+// @Param       min_age      query  int     false  "Minimum age in full years"
+// @Param       max_age      query  int     false  "Maximum age in full years"
 // @Success     200    {array}   models.Person
+// @Header      200    {string}  X-Cache  "HIT when the page was served from the cache, MISS when it was loaded"
+// @Header      200    {integer} Age      "Seconds since a cached page was loaded, on hits"
+// @Failure     400    {object}  map[string]string
+// @Failure     403    {object}  map[string]string
 // @Failure     500    {object}  map[string]string
-// @Router      /get-people/{name} [get]
+// @Router      /people/info/name/{name} [get]
 func (h *PersonHandler) GetPeopleByName(c *gin.Context) {
 	name := c.Param("name")
 
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		h.Logger.Warn("Invalid page number")
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": "Invalid page number"})
+	page, limit, msg := bindPagination(c)
+	if msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
 		return
 	}
 
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil || limit < 1 {
-		h.Logger.Warn("Invalid limit number")
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": "Invalid limit number"})
+	includeDeleted, ok := h.includeDeleted(c)
+	if !ok {
 		return
 	}
 
-	people, total, err := h.service.GetPeopleByName(name, page, limit)
+	query := models.PeopleQuery{Name: name, Page: page, Limit: limit, IncludeDeleted: includeDeleted}
+	if msg := bindPeopleFilters(c, &query); msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+	if msg := h.bindListing(c, &query); msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+
+	result, err := h.service.GetPeopleByName(c.Request.Context(), query)
 	if err != nil {
 		h.Logger.WithError(err).Error("Error searching people")
+		if _, ok := err.(*errors.AppError); ok {
+			h.handleServiceError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "errors": "Error searching people"})
 		return
 	}
 
-	if len(people) <= 0 {
+	if len(result.People) <= 0 {
 		h.Logger.Warn("No people found for name:", name)
-		people = []models.Person{}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    people,
-		"total":   total,
-		"page":    page,
-		"limit":   limit,
-	})
+	h.writePeoplePage(c, query, result)
+}
+
+// GetPeopleByPhone godoc
+// @Summary     Get people by phone number
+// @Description Finds people by a complete phone number (exact match on the normalized number) or by a partial number (prefix match)
+// @Tags        Person
+// @Accept      json
+// @Produce     json
+// @Param       phone  path      string  true  "Complete or partial phone number"
+// @Param       page   query     int     false "Page number" default(1)
+// @Param       limit  query     int     false "Results per page (at most 100)" default(10)
+// @Param       include_deleted  query  bool  false  "Include soft-deleted people (admin only)"
+// @Param       cursor         query  string  false  "Opaque next_cursor or prev_cursor from a previous page; replaces page"
+// @Param       include_total  query  bool    false  "Count all matches for the total field" default(true)
+// @Param       sort           query  string  false  "Comma-separated sort fields, minus for descending, e.g. -created_at,name"
+// @Param       filter         query  string  false  "Filter expression, e.g. phone_prefix:+7701 or iin_prefix:0203"
+// @Success     200    {array}   models.Person
+// @Failure     400    {object}  map[string]string
+// @Failure     403    {object}  map[string]string
+// @Failure     500    {object}  map[string]string
+// @Router      /people/info/by-phone/{phone} [get]
+func (h *PersonHandler) GetPeopleByPhone(c *gin.Context) {
+	phone := c.Param("phone")
+
+	page, limit, msg := bindPagination(c)
+	if msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+
+	includeDeleted, ok := h.includeDeleted(c)
+	if !ok {
+		return
+	}
+
+	query := models.PeopleQuery{Phone: phone, Page: page, Limit: limit, IncludeDeleted: includeDeleted}
+	if msg := h.bindListing(c, &query); msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+
+	result, err := h.service.GetPeopleByPhone(c.Request.Context(), query)
+	if err != nil {
+		h.Logger.WithError(err).Error("Error searching people by phone")
+		h.handleServiceError(c, err)
+		return
+	}
+
+	if len(result.People) <= 0 {
+		h.Logger.Warn("No people found for phone:", phone)
+	}
+
+	h.writePeoplePage(c, query, result)
+}
+
+// ListPeople godoc
+// @Summary     List people
+// @Description Lists all people with optional sorting and filters, ordered by name by default
+// @Tags        Person
+// @Accept      json
+// @Produce     json
+// @Param       page   query     int     false "Page number" default(1)
+// @Param       limit  query     int     false "Results per page (at most 100)" default(10)
+// @Param       include_deleted  query  bool  false  "Include soft-deleted people (admin only)"
+// @Param       cursor         query  string  false  "Opaque next_cursor or prev_cursor from a previous page; replaces page"
+// @Param       include_total  query  bool    false  "Count all matches for the total field" default(true)
+// @Param       sort           query  string  false  "Comma-separated sort fields, minus for descending, e.g. -created_at,name"
+// @Param       filter         query  string  false  "Filter expression, e.g. phone_prefix:+7701 or iin_prefix:0203"
+// @Param       sex          query  string  false  "Filter by sex"  Enums(male, female)
+// @Param       born_after   query  string  false  "Born after date (YYYY-MM-DD)"
+// @Param       born_before  query  string  false  "Born before date (YYYY-MM-DD)"
+// @Param       min_age      query  int     false  "Minimum age in full years"
+// @Param       max_age      query  int     false  "Maximum age in full years"
+// @Success     200    {array}   models.Person
+// @Failure     400    {object}  map[string]string
+// @Failure     403    {object}  map[string]string
+// @Failure     500    {object}  map[string]string
+// @Router      /people/info [get]
+func (h *PersonHandler) ListPeople(c *gin.Context) {
+	page, limit, msg := bindPagination(c)
+	if msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+
+	includeDeleted, ok := h.includeDeleted(c)
+	if !ok {
+		return
+	}
+
+	query := models.PeopleQuery{Page: page, Limit: limit, IncludeDeleted: includeDeleted}
+	if msg := bindPeopleFilters(c, &query); msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+	if msg := h.bindListing(c, &query); msg != "" {
+		h.Logger.Warn(msg)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": msg})
+		return
+	}
+
+	result, err := h.service.ListPeople(c.Request.Context(), query)
+	if err != nil {
+		h.Logger.WithError(err).Error("Error listing people")
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.writePeoplePage(c, query, result)
+}
+
+// UpdatePerson godoc
+// @Summary     Update a person
+// @Description Replaces all fields of the person with the given IIN
+// @Tags        Person
+// @Accept      json
+// @Produce     json
+// @Param       iin     path  string         true  "IIN number"
+// @Param       person  body  models.Person  true  "Person data"
+// @Success     200  {object}  models.Person
+// @Failure     400  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /people/info/iin/{iin} [put]
+func (h *PersonHandler) UpdatePerson(c *gin.Context) {
+	iin := c.Param("iin")
+
+	var person models.Person
+	if err := c.ShouldBindJSON(&person); err != nil {
+		h.Logger.WithError(err).Warn("Invalid request format")
+		c.Error(errors.ErrBadRequest)
+		return
+	}
+
+	updated, err := h.service.UpdatePerson(c.Request.Context(), iin, person)
+	if err != nil {
+		h.Logger.WithError(err).Error("Failed to update person")
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.Logger.Info("Person updated successfully: ", updated.IIN)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": updated})
+}
+
+// PatchPerson godoc
+// @Summary     Partially update a person
+// @Description Applies a JSON merge patch (RFC 7386) to the person with the given IIN
+// @Tags        Person
+// @Accept      json
+// @Produce     json
+// @Param       iin    path  string  true  "IIN number"
+// @Param       patch  body  object  true  "Merge patch document"
+// @Success     200  {object}  models.Person
+// @Failure     400  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /people/info/iin/{iin} [patch]
+func (h *PersonHandler) PatchPerson(c *gin.Context) {
+	iin := c.Param("iin")
+
+	patch, err := c.GetRawData()
+	if err != nil || !json.Valid(patch) {
+		h.Logger.WithError(err).Warn("Invalid request format")
+		c.Error(errors.ErrBadRequest)
+		return
+	}
+
+	updated, err := h.service.PatchPerson(c.Request.Context(), iin, patch)
+	if err != nil {
+		h.Logger.WithError(err).Error("Failed to patch person")
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.Logger.Info("Person patched successfully: ", updated.IIN)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": updated})
+}
+
+// DeletePerson godoc
+// @Summary     Delete a person
+// @Description Soft-deletes the person with the given IIN; it is purged after the retention period
+// @Tags        Person
+// @Accept      json
+// @Produce     json
+// @Param       iin     path    string  true   "IIN number"
+// @Param       X-User  header  string  false  "User performing the deletion"
+// @Success     200  {object}  map[string]bool
+// @Failure     400  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /people/info/iin/{iin} [delete]
+func (h *PersonHandler) DeletePerson(c *gin.Context) {
+	iin := c.Param("iin")
+
+	if err := h.service.DeletePerson(c.Request.Context(), iin, c.GetHeader(ActorHeader)); err != nil {
+		h.Logger.WithError(err).Error("Failed to delete person")
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.Logger.Info("Person deleted successfully: ", iin)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// RestorePerson godoc
+// @Summary     Restore a deleted person
+// @Description Restores a soft-deleted person that has not been purged yet (admin only)
+// @Tags        Person
+// @Accept      json
+// @Produce     json
+// @Param       iin            path    string  true  "IIN number"
+// @Param       X-Admin-Token  header  string  true  "Admin token"
+// @Success     200  {object}  models.Person
+// @Failure     403  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /people/info/iin/{iin}/restore [post]
+func (h *PersonHandler) RestorePerson(c *gin.Context) {
+	iin := c.Param("iin")
+
+	person, err := h.service.RestorePerson(c.Request.Context(), iin)
+	if err != nil {
+		h.Logger.WithError(err).Error("Failed to restore person")
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.Logger.Info("Person restored successfully: ", iin)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": person})
+}
+
+// includeDeleted parses the include_deleted query flag, which only admins may set.
+func (h *PersonHandler) includeDeleted(c *gin.Context) (bool, bool) {
+	includeDeleted, err := strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
+	if err != nil {
+		h.Logger.Warn("Invalid include_deleted flag")
+		c.Error(&errors.AppError{Code: errors.ErrBadRequest.Code, Message: errors.ErrBadRequest.Message, IsDefault: true})
+		return false, false
+	}
+
+	if includeDeleted && !middleware.IsAdmin(c) {
+		h.Logger.Warn("include_deleted requested without admin privileges")
+		c.Error(&errors.AppError{Code: errors.ErrForbidden.Code, Message: errors.ErrForbidden.Message, IsDefault: true})
+		return false, false
+	}
+
+	return includeDeleted, true
+}
+
+func (h *PersonHandler) handleServiceError(c *gin.Context, err error) {
+	if appErr, ok := err.(*errors.AppError); ok {
+		rendered := *appErr
+		rendered.IsDefault = true
+		c.Error(&rendered)
+	} else {
+		c.Error(errors.ErrInternalServer)
+	}
 }
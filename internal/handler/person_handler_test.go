@@ -1,30 +1,101 @@
 package handler_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ddProgerGo/task-kaspi/internal/filter"
 	"github.com/ddProgerGo/task-kaspi/internal/handler"
+	"github.com/ddProgerGo/task-kaspi/internal/middleware"
 	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/pagination"
+	"github.com/ddProgerGo/task-kaspi/internal/sheet"
+	"github.com/ddProgerGo/task-kaspi/internal/utils"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var cursors = pagination.NewCodec([]byte("test-secret"))
+
 type MockPersonService struct {
 	mock.Mock
 }
 
-func (m *MockPersonService) SavePerson(person models.Person) error {
-	args := m.Called(person)
+func (m *MockPersonService) SavePerson(ctx context.Context, person models.Person, onConflict string) (*models.SaveResult, error) {
+	args := m.Called(person, onConflict)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SaveResult), args.Error(1)
+}
+
+func (m *MockPersonService) GetPersonByIIN(ctx context.Context, iin string, includeDeleted bool) (*models.Person, error) {
+	args := m.Called(iin, includeDeleted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Person), args.Error(1)
+}
+
+func (m *MockPersonService) ListPeople(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PeoplePage), args.Error(1)
+}
+
+func (m *MockPersonService) ExportPeople(ctx context.Context, query models.PeopleQuery, sink models.PersonSink) error {
+	args := m.Called(query, sink)
 	return args.Error(0)
 }
 
-func (m *MockPersonService) GetPersonByIIN(iin string) (*models.Person, error) {
+func (m *MockPersonService) GetPeopleByName(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	args := m.Called(query)
+	if page, ok := args.Get(0).(*models.PeoplePage); ok {
+		return page, args.Error(1)
+	}
+	return &models.PeoplePage{People: args.Get(0).([]models.Person)}, args.Error(1)
+}
+
+func (m *MockPersonService) GetPeopleByPhone(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	args := m.Called(query)
+	return &models.PeoplePage{People: args.Get(0).([]models.Person)}, args.Error(1)
+}
+
+func (m *MockPersonService) UpdatePerson(ctx context.Context, iin string, person models.Person) (*models.Person, error) {
+	args := m.Called(iin, person)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Person), args.Error(1)
+}
+
+func (m *MockPersonService) PatchPerson(ctx context.Context, iin string, patch []byte) (*models.Person, error) {
+	args := m.Called(iin, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Person), args.Error(1)
+}
+
+func (m *MockPersonService) DeletePerson(ctx context.Context, iin string, deletedBy string) error {
+	args := m.Called(iin, deletedBy)
+	return args.Error(0)
+}
+
+func (m *MockPersonService) RestorePerson(ctx context.Context, iin string) (*models.Person, error) {
 	args := m.Called(iin)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -32,9 +103,17 @@ func (m *MockPersonService) GetPersonByIIN(iin string) (*models.Person, error) {
 	return args.Get(0).(*models.Person), args.Error(1)
 }
 
-func (m *MockPersonService) GetPeopleByName(name string, page, limit int) ([]models.Person, int, error) {
-	args := m.Called(name, page, limit)
-	return args.Get(0).([]models.Person), 0, args.Error(1)
+func (m *MockPersonService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	args := m.Called(retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPersonService) ImportPeople(ctx context.Context, rows sheet.Reader, dryRun bool) (*models.ImportReport, error) {
+	args := m.Called(rows, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportReport), args.Error(1)
 }
 
 func TestGetPersonByIIN(t *testing.T) {
@@ -43,28 +122,31 @@ func TestGetPersonByIIN(t *testing.T) {
 	validIIN := "020304550283"
 	person := &models.Person{IIN: validIIN, Name: "John Doe", Phone: "1234567890"}
 
-	mockService.On("GetPersonByIIN", validIIN).Return(person, nil)
+	mockService.On("GetPersonByIIN", validIIN, false).Return(person, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/people/info/iin/"+validIIN, nil)
 	c.Params = append(c.Params, gin.Param{Key: "iin", Value: validIIN})
 
 	logger := logrus.New()
-	h := handler.NewPersonHandler(mockService, logger)
+	h := handler.NewPersonHandler(mockService, logger, cursors)
 	h.GetPersonByIIN(c)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var response models.Person
+	var response struct {
+		Data models.Person `json:"data"`
+	}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, *person, response)
+	assert.Equal(t, *person, response.Data)
 }
 
 func TestSavePerson(t *testing.T) {
 	mockService := new(MockPersonService)
 
-	mockService.On("SavePerson", mock.Anything).Return(nil)
+	mockService.On("SavePerson", mock.Anything, models.OnConflictError).Return(&models.SaveResult{ID: 1, Status: models.SaveCreated}, nil)
 
 	body := `{"IIN": "020304550283", "Name": "Dulat Nurmeden", "Phone": "1234567890"}`
 	req := httptest.NewRequest(http.MethodPost, "/save-person", strings.NewReader(body))
@@ -75,8 +157,582 @@ func TestSavePerson(t *testing.T) {
 	c.Request = req
 
 	logger := logrus.New()
-	h := handler.NewPersonHandler(mockService, logger)
+	h := handler.NewPersonHandler(mockService, logger, cursors)
 	h.SavePerson(c)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestSavePersonConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockPersonService)
+	mockService.On("SavePerson", mock.Anything, models.OnConflictError).Return(nil, errors.ErrDuplicateIIN.WithID(42))
+	mockService.On("SavePerson", mock.Anything, models.OnConflictUpdate).Return(&models.SaveResult{ID: 42, Status: models.SaveUpdated}, nil)
+
+	logger := logrus.New()
+	h := handler.NewPersonHandler(mockService, logger, cursors)
+	router := gin.New()
+	router.Use(middleware.ErrorHandlingMiddleware(logger))
+	router.POST("/people/info", h.SavePerson)
+
+	post := func(query string) *httptest.ResponseRecorder {
+		body := `{"iin": "020304550283", "name": "Dulat Nurmeden", "phone": "+77011234567"}`
+		req := httptest.NewRequest(http.MethodPost, "/people/info"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"success":false,"error":"Person with this IIN already exists","field":"iin","id":42}`, w.Body.String())
+
+	w = post("?on_conflict=update")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"success":true,"data":{"id":42,"status":"updated"}}`, w.Body.String())
+
+	w = post("?on_conflict=replace")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNumberOfCalls(t, "SavePerson", 2)
+}
+
+func TestUpdatePerson(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	validIIN := "020304550283"
+	person := models.Person{IIN: validIIN, Name: "Dulat Nurmeden", Phone: "77011234567"}
+	mockService.On("UpdatePerson", validIIN, person).Return(&person, nil)
+
+	body := `{"iin": "020304550283", "name": "Dulat Nurmeden", "phone": "77011234567"}`
+	req := httptest.NewRequest(http.MethodPut, "/people/info/iin/"+validIIN, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = append(c.Params, gin.Param{Key: "iin", Value: validIIN})
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+	h.UpdatePerson(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestPatchPerson(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	validIIN := "020304550283"
+	patch := []byte(`{"phone": "77017654321"}`)
+	person := &models.Person{IIN: validIIN, Name: "Dulat Nurmeden", Phone: "77017654321"}
+	mockService.On("PatchPerson", validIIN, patch).Return(person, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/people/info/iin/"+validIIN, strings.NewReader(string(patch)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = append(c.Params, gin.Param{Key: "iin", Value: validIIN})
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+	h.PatchPerson(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeletePersonNotFound(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	validIIN := "020304550283"
+	mockService.On("DeletePerson", validIIN, "").Return(errors.ErrNotFound)
+
+	logger := logrus.New()
+	h := handler.NewPersonHandler(mockService, logger, cursors)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlingMiddleware(logger))
+	router.DELETE("/people/info/iin/:iin", h.DeletePerson)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/people/info/iin/"+validIIN, nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetPersonByIINIncludeDeletedRequiresAdmin(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	logger := logrus.New()
+	h := handler.NewPersonHandler(mockService, logger, cursors)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlingMiddleware(logger))
+	router.Use(middleware.AdminMiddleware("secret"))
+	router.GET("/people/info/iin/:iin", h.GetPersonByIIN)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/iin/020304550283?include_deleted=true", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	validIIN := "020304550283"
+	person := &models.Person{IIN: validIIN, Name: "John Doe", Phone: "77011234567"}
+	mockService.On("GetPersonByIIN", validIIN, true).Return(person, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/people/info/iin/"+validIIN+"?include_deleted=true", nil)
+	req.Header.Set(middleware.AdminTokenHeader, "secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestBatchCheckIIN(t *testing.T) {
+	h := handler.NewPersonHandler(new(MockPersonService), logrus.New(), cursors)
+
+	body := "020304550283\n12345\n\n020304550284\n"
+	req := httptest.NewRequest(http.MethodPost, "/iin_check/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.BatchCheckIIN(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 3)
+
+	var items []handler.IINBatchItem
+	for _, line := range lines {
+		var item handler.IINBatchItem
+		assert.NoError(t, json.Unmarshal([]byte(line), &item))
+		items = append(items, item)
+	}
+
+	assert.NotNil(t, items[0].Result)
+	assert.Equal(t, "length", items[1].Error.Reason)
+	assert.Equal(t, "checksum", items[2].Error.Reason)
+}
+
+func TestBatchCheckIINJSONArray(t *testing.T) {
+	h := handler.NewPersonHandler(new(MockPersonService), logrus.New(), cursors)
+
+	req := httptest.NewRequest(http.MethodPost, "/iin_check/batch", strings.NewReader(`["020304550283", "02030455028a"]`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.BatchCheckIIN(c)
+
+	var items []handler.IINBatchItem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	assert.Len(t, items, 2)
+	assert.Nil(t, items[0].Error)
+	assert.Equal(t, "format", items[1].Error.Reason)
+}
+
+func TestBatchCheckIINTruncatedJSONArray(t *testing.T) {
+	h := handler.NewPersonHandler(new(MockPersonService), logrus.New(), cursors)
+
+	for _, body := range []string{`["020304550283"`, `["020304550283",`, `[`} {
+		req := httptest.NewRequest(http.MethodPost, "/iin_check/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.BatchCheckIIN(c)
+
+		var items []handler.IINBatchItem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &items), body)
+		if assert.NotEmpty(t, items, body) {
+			last := items[len(items)-1]
+			assert.NotNil(t, last.Error, body)
+			assert.Equal(t, "malformed_input", last.Error.Reason, body)
+		}
+	}
+}
+
+func TestCheckIDDetectsType(t *testing.T) {
+	logger := logrus.New()
+	h := handler.NewPersonHandler(new(MockPersonService), logger, cursors)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlingMiddleware(logger))
+	router.GET("/id_check/:number", h.CheckID)
+
+	tests := []struct {
+		number   string
+		code     int
+		expected string
+	}{
+		{"020304550283", http.StatusOK, "iin"},
+		{"140340001234", http.StatusOK, "bin"},
+		{"140340001235", http.StatusBadRequest, ""},
+		{"140370001234", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/id_check/"+tt.number, nil))
+		assert.Equal(t, tt.code, w.Code, tt.number)
+
+		if tt.code == http.StatusOK {
+			var info utils.IDInfo
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+			assert.Equal(t, tt.expected, info.Type)
+		}
+	}
+}
+
+func TestCheckIINAsOf(t *testing.T) {
+	h := handler.NewPersonHandler(new(MockPersonService), logrus.New(), cursors)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/iin_check/020304550283?as_of=2020-03-04", nil)
+	c.Params = append(c.Params, gin.Param{Key: "iin", Value: "020304550283"})
+	h.CheckIIN(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var info utils.IINInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, "04.03.2002", info.DateOfBirth)
+	assert.Equal(t, "2002-03-04", info.BirthDate)
+	assert.Equal(t, 18, info.Age)
+	assert.True(t, info.IsAdult)
+	assert.False(t, info.BornInFuture)
+	assert.Equal(t, 2000, info.Century)
+	assert.Equal(t, 5, info.CenturyDigit)
+	assert.Equal(t, "5028", info.SerialNumber)
+	assert.Equal(t, 3, info.ControlDigit)
+	assert.Equal(t, 1, info.ChecksumPass)
+}
+
+func TestGetPeopleByNameFilters(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	minAge := 18
+	bornBefore := time.Date(2005, time.January, 1, 0, 0, 0, 0, time.UTC)
+	expected := models.PeopleQuery{
+		Name:       "Dulat",
+		Mode:       models.SearchModeContains,
+		Page:       1,
+		Limit:      10,
+		Sex:        "male",
+		BornBefore: &bornBefore,
+		MinAge:     &minAge,
+	}
+	mockService.On("GetPeopleByName", expected).Return([]models.Person{}, nil)
+
+	logger := logrus.New()
+	h := handler.NewPersonHandler(mockService, logger, cursors)
+
+	router := gin.New()
+	router.GET("/people/info/phone/:name", h.GetPeopleByName)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/phone/Dulat?sex=male&born_before=2005-01-01&min_age=18", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/phone/Dulat?min_age=30&max_age=20", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetPeopleByPhone(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	person := models.Person{IIN: "020304550283", Name: "Dulat Nurmeden", Phone: "+77011234567"}
+	mockService.On("GetPeopleByPhone", models.PeopleQuery{Phone: "+7 701 123", Page: 1, Limit: 10}).
+		Return([]models.Person{person}, nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.GET("/people/info/by-phone/:phone", h.GetPeopleByPhone)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/by-phone/+7%20701%20123", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeprecatedNameSearchAlias(t *testing.T) {
+	mockService := new(MockPersonService)
+	mockService.On("GetPeopleByName", models.PeopleQuery{Name: "Dulat", Mode: models.SearchModeContains, Page: 1, Limit: 10}).Return([]models.Person{}, nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.GET("/people/info/phone/:name", middleware.Deprecated("/people/info/name/:name"), h.GetPeopleByName)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/phone/Dulat", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.Equal(t, `</people/info/name/Dulat>; rel="successor-version"`, w.Header().Get("Link"))
+}
+
+func TestGetPeopleByNameFuzzyMode(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	expected := models.PeopleQuery{Name: "Нурмедён", Mode: models.SearchModeFuzzy, Threshold: 0.5, Page: 1, Limit: 10}
+	mockService.On("GetPeopleByName", expected).Return([]models.Person{}, nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.GET("/people/info/name/:name", h.GetPeopleByName)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Нурмедён?mode=fuzzy&threshold=0.5", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Dulat?mode=regex", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetPeopleByNamePart(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	expected := models.PeopleQuery{Name: "Нурмедён", NamePart: models.NamePartLast, Mode: models.SearchModePrefix, Page: 1, Limit: 10}
+	mockService.On("GetPeopleByName", expected).Return([]models.Person{}, nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.GET("/people/info/name/:name", h.GetPeopleByName)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Нурмедён?mode=prefix&name_part=last_name", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Dulat?name_part=nickname", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetPeopleByNameCursor(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	score := 0.5
+	next := &models.Cursor{Sort: "-score,name", Values: []interface{}{score, "Dulat Nurmeden"}, ID: 7}
+	mockService.On("GetPeopleByName", models.PeopleQuery{Name: "Dulat", Mode: models.SearchModeContains, Page: 1, Limit: 1}).
+		Return(&models.PeoplePage{People: []models.Person{{ID: 7, Name: "Dulat Nurmeden", Score: &score}}, Next: next}, nil)
+	mockService.On("GetPeopleByName", models.PeopleQuery{Name: "Dulat", Mode: models.SearchModeContains, Page: 1, Limit: 1, Cursor: next, SkipTotal: true}).
+		Return([]models.Person{}, nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.GET("/people/info/name/:name", h.GetPeopleByName)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Dulat?limit=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var first struct {
+		NextCursor *string `json:"next_cursor"`
+		PrevCursor *string `json:"prev_cursor"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.Nil(t, first.PrevCursor)
+	if assert.NotNil(t, first.NextCursor) {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Dulat?limit=1&include_total=false&cursor="+*first.NextCursor, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), `"total"`)
+		assert.NotContains(t, w.Body.String(), `"page"`)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Dulat?page=2&cursor="+*first.NextCursor, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+	mockService.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Dulat?cursor=e30.AAAA", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListPeopleSortAndFilter(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	expected := models.PeopleQuery{
+		Mode:   models.SearchModeContains,
+		Page:   1,
+		Limit:  100,
+		Sort:   "-created_at,name",
+		Filter: &filter.Expr{Op: filter.OpOr, Terms: []filter.Expr{{Field: "phone_prefix", Value: "+7701"}, {Field: "iin_prefix", Value: "0203"}}},
+	}
+	mockService.On("ListPeople", expected).Return(&models.PeoplePage{}, nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.GET("/people/info", h.ListPeople)
+
+	query := url.Values{"limit": {"100"}, "sort": {"-created_at, name"}, "filter": {"phone_prefix:+7701 or iin_prefix:0203"}}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info?"+query.Encode(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"success":true,"data":[],"page":1,"limit":100,"next_cursor":null,"prev_cursor":null}`, w.Body.String())
+	mockService.AssertExpectations(t)
+
+	for _, bad := range []string{"limit=101", "filter=" + url.QueryEscape("iin_prefix:02 and")} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info?"+bad, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, bad)
+	}
+}
+
+func TestImportPeopleReport(t *testing.T) {
+	mockService := new(MockPersonService)
+	report := &models.ImportReport{DryRun: true, Total: 1, Rejected: 1, Rows: []models.ImportRow{
+		{Row: 2, IIN: "123", Status: models.ImportRejected, Error: "iin failed len=12 validation", Field: "iin"},
+	}}
+	mockService.On("ImportPeople", mock.Anything, true).Return(report, nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.POST("/people/import", h.ImportPeople)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "people.csv")
+	assert.NoError(t, err)
+	part.Write([]byte("name,iin,phone\nDulat,123,87011234567\n"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/people/import?dry_run=true&report=csv", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "row,iin,status,error,field\n2,123,rejected,iin failed len=12 validation,iin\n", w.Body.String())
+	mockService.AssertExpectations(t)
+
+	req = httptest.NewRequest(http.MethodPost, "/people/import", strings.NewReader("name,iin,phone\n"))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestExportPeople(t *testing.T) {
+	mockService := new(MockPersonService)
+	mockService.On("ExportPeople", models.PeopleQuery{Mode: models.SearchModeContains, Sex: "female"}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			sink := args.Get(1).(models.PersonSink)
+			assert.NoError(t, sink.Begin())
+			assert.NoError(t, sink.Write(&models.Person{Name: "Алина Петрова", IIN: "020304650284", Phone: "+77051234567"}))
+		}).
+		Return(nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.GET("/people/export", h.ExportPeople)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/export?format=ndjson&columns=name,phone&mask=phone&sex=female", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"name":"Алина Петрова","phone":"+7705*****67"}`+"\n", w.Body.String())
+	mockService.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/export?mask=name", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestExportPeopleMasksByDefault(t *testing.T) {
+	person := &models.Person{Name: "Алина Петрова", IIN: "020304650284", Phone: "+77051234567"}
+	mockService := new(MockPersonService)
+	mockService.On("ExportPeople", models.PeopleQuery{Mode: models.SearchModeContains}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			sink := args.Get(1).(models.PersonSink)
+			assert.NoError(t, sink.Begin())
+			assert.NoError(t, sink.Write(person))
+		}).
+		Return(nil)
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+	router := gin.New()
+	router.Use(middleware.ErrorHandlingMiddleware(logrus.New()))
+	router.Use(middleware.AdminMiddleware("secret"))
+	router.GET("/people/export", h.ExportPeople)
+
+	get := func(target string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if admin {
+			req.Header.Set(middleware.AdminTokenHeader, "secret")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/people/export?format=ndjson&columns=iin,phone", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"iin":"0203******84","phone":"+7705*****67"}`+"\n", w.Body.String())
+
+	for _, target := range []string{"/people/export?mask=none", "/people/export?mask=phone", "/people/export?mask="} {
+		w = get(target, false)
+		assert.Equal(t, http.StatusForbidden, w.Code, target)
+	}
+
+	w = get("/people/export?format=ndjson&columns=iin,phone&mask=none", true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"iin":"020304650284","phone":"+77051234567"}`+"\n", w.Body.String())
+}
+
+func TestGetPeopleByNameCacheHeaders(t *testing.T) {
+	mockService := new(MockPersonService)
+
+	query := models.PeopleQuery{Name: "Dulat", Mode: models.SearchModeContains, Page: 1, Limit: 10}
+	people := []models.Person{{ID: 7, Name: "Dulat Nurmeden"}}
+	mockService.On("GetPeopleByName", query).
+		Return(&models.PeoplePage{People: people, CacheStatus: models.CacheMiss}, nil).Once()
+	mockService.On("GetPeopleByName", query).
+		Return(&models.PeoplePage{People: people, CacheStatus: models.CacheHit, CachedAt: time.Now().Add(-90 * time.Second)}, nil).Once()
+
+	h := handler.NewPersonHandler(mockService, logrus.New(), cursors)
+
+	router := gin.New()
+	router.GET("/people/info/name/:name", h.GetPeopleByName)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Dulat", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get(handler.CacheStatusHeader))
+	assert.Empty(t, w.Header().Get("Age"))
+	miss := w.Body.String()
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/info/name/Dulat", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get(handler.CacheStatusHeader))
+	assert.Equal(t, "90", w.Header().Get("Age"))
+	assert.JSONEq(t, miss, w.Body.String())
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// PurgeJob periodically hard-deletes people whose soft-deletion retention period has expired.
+type PurgeJob struct {
+	service   service.PersonServiceInterface
+	Logger    *logrus.Logger
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+func NewPurgeJob(service service.PersonServiceInterface, logger *logrus.Logger, interval, retention time.Duration) *PurgeJob {
+	return &PurgeJob{service: service, Logger: logger, Interval: interval, Retention: retention}
+}
+
+// Run blocks until ctx is cancelled, purging expired records once per interval.
+func (j *PurgeJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	j.Logger.Info("Purge job started")
+	for {
+		j.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.Logger.Info("Purge job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *PurgeJob) purge(ctx context.Context) {
+	if _, err := j.service.PurgeDeleted(ctx, j.Retention); err != nil {
+		j.Logger.WithError(err).Error("Purge job run failed")
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AdminTokenHeader = "X-Admin-Token"
+	isAdminKey       = "is_admin"
+)
+
+// AdminMiddleware marks the request as made by an admin when the X-Admin-Token
+// header matches the configured token. An empty token disables admin access.
+func AdminMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(AdminTokenHeader)
+		if token != "" && subtle.ConstantTimeCompare([]byte(header), []byte(token)) == 1 {
+			c.Set(isAdminKey, true)
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects requests that were not marked as admin by AdminMiddleware.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c) {
+			c.Error(&errors.AppError{Code: errors.ErrForbidden.Code, Message: errors.ErrForbidden.Message, IsDefault: true})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func IsAdmin(c *gin.Context) bool {
+	return c.GetBool(isAdminKey)
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks responses of a deprecated route and points clients to its
+// successor. Path parameters in successor (":name") are filled from the request.
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link := successor
+		for _, param := range c.Params {
+			link = strings.Replace(link, ":"+param.Key, url.PathEscape(param.Value), 1)
+		}
+
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+link+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
@@ -26,15 +26,20 @@ func ErrorHandlingMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 
 				if appErr, ok := err.Err.(*errors.AppError); ok {
 
+					body := gin.H{"correct": false, "error": appErr.Message}
 					if appErr.IsDefault {
-						c.JSON(appErr.Code, gin.H{"success": false, "error": appErr.Message})
-						c.Abort()
-						return
-					} else {
-						c.JSON(appErr.Code, gin.H{"correct": false, "error": appErr.Message})
-						c.Abort()
-						return
+						body = gin.H{"success": false, "error": appErr.Message}
 					}
+					if appErr.Field != "" {
+						body["field"] = appErr.Field
+					}
+					if appErr.ID != 0 {
+						body["id"] = appErr.ID
+					}
+
+					c.JSON(appErr.Code, body)
+					c.Abort()
+					return
 				}
 			}
 
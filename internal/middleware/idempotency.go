@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyStore keeps the requests seen with an Idempotency-Key, see
+// repository.IdempotencyRepository.
+type IdempotencyStore interface {
+	Claim(ctx context.Context, key, fingerprint string, lease time.Duration) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, record models.IdempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency makes a route safe to retry. The first request carrying an
+// Idempotency-Key is processed and, if it succeeds, its response is kept for
+// ttl and replayed to later requests with the same key, query and body.
+// Reusing a key for a different request is rejected with 422, and a retry
+// arriving while the original is still in flight with 409. Failed requests
+// are not remembered, so they may be retried with the same key. Requests
+// without the header are passed through.
+//
+// While the request is in flight the key is only leased for lease, which
+// should cover the handler's own timeout: if the process dies before the
+// outcome is recorded, the key frees up once the lease runs out instead of
+// answering 409 for the whole ttl.
+func Idempotency(store IdempotencyStore, ttl, lease time.Duration, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortIdempotency(c, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortIdempotency(c, http.StatusBadRequest, errors.ErrBadRequest.Message)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(c.Request.Method, c.FullPath(), c.Request.URL.Query().Encode(), body)
+		record, err := store.Claim(c.Request.Context(), key, fingerprint, lease)
+		if err != nil {
+			logger.WithError(err).Error("Failed to claim idempotency key")
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if record != nil {
+			switch {
+			case record.Fingerprint != fingerprint:
+				abortIdempotency(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case !record.Completed():
+				abortIdempotency(c, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+			default:
+				logger.WithField("idempotency_key", key).Info("Replaying stored response")
+				c.Header(IdempotencyReplayedHeader, "true")
+				c.Data(record.Status, record.ContentType, record.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		// The outcome is recorded even when the client has gone away, since
+		// that is exactly when it is going to retry.
+		ctx := context.WithoutCancel(c.Request.Context())
+		recorded := false
+		defer func() {
+			// Reached without an outcome when the request failed or the
+			// handler panicked; the panic carries on to the recovery
+			// middleware.
+			if recorded {
+				return
+			}
+			if err := store.Release(ctx, key); err != nil {
+				logger.WithError(err).Error("Failed to release idempotency key")
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if len(c.Errors) > 0 || status < 200 || status >= 300 {
+			return
+		}
+
+		// A response that fails to be stored keeps its lease: the write
+		// already happened, so retrying it before the lease ends would not
+		// be safe either.
+		recorded = true
+		response := models.IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := store.Complete(ctx, key, response, ttl); err != nil {
+			logger.WithError(err).Error("Failed to store idempotent response")
+		}
+	}
+}
+
+// requestFingerprint identifies a request by its route, query and body. The
+// query is expected with its parameters sorted, as url.Values.Encode gives
+// it, and JSON bodies are compacted with their object keys sorted, so that
+// parameter order, formatting and key order do not count as a different
+// request.
+func requestFingerprint(method, route, query string, body []byte) string {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err == nil && !decoder.More() {
+		if canonical, err := json.Marshal(value); err == nil {
+			body = canonical
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + route + "?" + query + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func abortIdempotency(c *gin.Context, code int, message string) {
+	c.Error(&errors.AppError{Code: code, Message: message, IsDefault: true, Field: "idempotency_key"})
+	c.Abort()
+}
+
+// responseRecorder keeps a copy of the response body as it is written.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
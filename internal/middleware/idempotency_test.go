@@ -0,0 +1,162 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/middleware"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryIdempotencyStore map[string]models.IdempotencyRecord
+
+func (m memoryIdempotencyStore) Claim(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotencyRecord, error) {
+	if record, ok := m[key]; ok {
+		return &record, nil
+	}
+	m[key] = models.IdempotencyRecord{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (m memoryIdempotencyStore) Complete(ctx context.Context, key string, record models.IdempotencyRecord, ttl time.Duration) error {
+	m[key] = record
+	return nil
+}
+
+func (m memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := memoryIdempotencyStore{}
+	calls := 0
+	fail := false
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlingMiddleware(logrus.New()))
+	router.POST("/people/info", middleware.Idempotency(store, time.Hour, time.Minute, logrus.New()), func(c *gin.Context) {
+		calls++
+		if fail {
+			c.Error(&errors.AppError{Code: http.StatusInternalServerError, Message: "boom", IsDefault: true})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "call": calls})
+	})
+
+	post := func(key, body string, query ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/people/info"+strings.Join(query, ""), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("key-1", `{"iin":"020304500183","name":"Test"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"success":true,"call":1}`, w.Body.String())
+	assert.Empty(t, w.Header().Get(middleware.IdempotencyReplayedHeader))
+
+	// Same body, formatted differently: the stored response is replayed.
+	w = post("key-1", `{ "name": "Test", "iin": "020304500183" }`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"success":true,"call":1}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get(middleware.IdempotencyReplayedHeader))
+	assert.Equal(t, 1, calls)
+
+	w = post("key-1", `{"iin":"020304500183","name":"Other"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"success":false,"error":"Idempotency-Key was already used for a different request","field":"idempotency_key"}`, w.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// The same body with other query parameters is a different request too.
+	w = post("key-1", `{"iin":"020304500183","name":"Test"}`, "?on_conflict=update")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 1, calls)
+
+	w = post("key-3", `{}`, "?on_conflict=update&b=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = post("key-3", `{}`, "?b=1&on_conflict=update")
+	assert.Equal(t, "true", w.Header().Get(middleware.IdempotencyReplayedHeader))
+	assert.Equal(t, 2, calls)
+
+	// A failed request is forgotten, so the retry runs again.
+	fail = true
+	w = post("key-2", `{}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, store, "key-2")
+	fail = false
+	w = post("key-2", `{}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 4, calls)
+
+	w = post("", `{}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 5, calls)
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := memoryIdempotencyStore{}
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlingMiddleware(logrus.New()))
+	router.POST("/people/info", middleware.Idempotency(store, time.Hour, time.Minute, logrus.New()), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	// Fingerprint the body through a first request under another key.
+	req := httptest.NewRequest(http.MethodPost, "/people/info", strings.NewReader(`{}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "probe")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	store["key"] = models.IdempotencyRecord{Fingerprint: store["probe"].Fingerprint}
+
+	req = httptest.NewRequest(http.MethodPost, "/people/info", strings.NewReader(`{}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestIdempotencyReleasesOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := memoryIdempotencyStore{}
+	panics := true
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlingMiddleware(logrus.New()))
+	router.POST("/people/info", middleware.Idempotency(store, time.Hour, time.Minute, logrus.New()), func(c *gin.Context) {
+		if panics {
+			panic("boom")
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/people/info", strings.NewReader(`{}`))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post()
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, store, "key")
+
+	panics = false
+	w = post()
+	assert.Equal(t, http.StatusOK, w.Code)
+}
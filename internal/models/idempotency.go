@@ -0,0 +1,16 @@
+package models
+
+// IdempotencyRecord is what is remembered about a request made with an
+// Idempotency-Key. A zero Status means the original request is still being
+// processed; otherwise Status, ContentType and Body are its response.
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Completed reports whether the record holds a response to replay.
+func (r *IdempotencyRecord) Completed() bool {
+	return r.Status != 0
+}
@@ -0,0 +1,47 @@
+package models
+
+import "strconv"
+
+const (
+	ImportAccepted  = "accepted"
+	ImportDuplicate = "duplicate"
+	ImportRejected  = "rejected"
+	// ImportNotProcessed marks the rows left unwritten when an import stops
+	// on an error.
+	ImportNotProcessed = "not_processed"
+)
+
+// ImportRow is the outcome of one spreadsheet row. Row is the 1-based row
+// number in the file, counting the header.
+type ImportRow struct {
+	Row    int    `json:"row"`
+	IIN    string `json:"iin,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Field  string `json:"field,omitempty"`
+}
+
+// ImportReport summarises a bulk import. In a dry run nothing is written and
+// accepted rows are those that would have been inserted. Error is set when
+// the import stopped part way: the accepted rows were written, and the rest
+// are reported as not processed.
+type ImportReport struct {
+	DryRun       bool        `json:"dry_run"`
+	Total        int         `json:"total"`
+	Accepted     int         `json:"accepted"`
+	Duplicates   int         `json:"duplicates"`
+	Rejected     int         `json:"rejected"`
+	NotProcessed int         `json:"not_processed"`
+	Error        string      `json:"error,omitempty"`
+	Rows         []ImportRow `json:"rows"`
+}
+
+// Records returns the report as spreadsheet rows, starting with a header.
+func (r *ImportReport) Records() [][]string {
+	records := make([][]string, 0, len(r.Rows)+1)
+	records = append(records, []string{"row", "iin", "status", "error", "field"})
+	for _, row := range r.Rows {
+		records = append(records, []string{strconv.Itoa(row.Row), row.IIN, row.Status, row.Error, row.Field})
+	}
+	return records
+}
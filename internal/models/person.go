@@ -1,8 +1,127 @@
 package models
 
+import (
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/filter"
+)
+
 type Person struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name" validate:"required,min=2,max=50"`
-	IIN   string `json:"iin" validate:"required,len=12,numeric"`
-	Phone string `json:"phone" validate:"required,len=11,numeric"`
+	ID            int        `json:"id"`
+	Name          string     `json:"name" validate:"required,min=2,max=152"`
+	LastName      string     `json:"last_name,omitempty" validate:"max=50"`
+	FirstName     string     `json:"first_name" validate:"required,max=50"`
+	MiddleName    string     `json:"middle_name,omitempty" validate:"max=50"`
+	IIN           string     `json:"iin" validate:"required,len=12,numeric"`
+	Phone         string     `json:"phone" validate:"required,e164"`
+	BirthDate     string     `json:"birth_date,omitempty"`
+	Sex           string     `json:"sex,omitempty"`
+	PhoneOperator string     `json:"phone_operator,omitempty"`
+	PhoneType     string     `json:"phone_type,omitempty"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy     *string    `json:"deleted_by,omitempty"`
+	Score         *float64   `json:"score,omitempty"`
+}
+
+const (
+	SearchModeExact    = "exact"
+	SearchModePrefix   = "prefix"
+	SearchModeContains = "contains"
+	SearchModeFuzzy    = "fuzzy"
+
+	NamePartLast   = "last_name"
+	NamePartFirst  = "first_name"
+	NamePartMiddle = "middle_name"
+
+	DefaultSimilarityThreshold = 0.3
+)
+
+// Conflict modes of SavePerson, deciding what happens when a person with the
+// same IIN is already stored: fail, overwrite the stored person, or keep it.
+const (
+	OnConflictError  = "error"
+	OnConflictUpdate = "update"
+	OnConflictIgnore = "ignore"
+)
+
+const (
+	SaveCreated = "created"
+	SaveUpdated = "updated"
+	SaveIgnored = "ignored"
+)
+
+// SaveResult tells which person a save refers to and what was done with it.
+type SaveResult struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+// PeopleQuery describes a paginated people search with optional filters.
+// Name is matched against the full name, or against a single part when
+// NamePart is set, according to Mode; in fuzzy mode Threshold is the minimum
+// trigram word similarity. Phone is an E.164 number matched exactly, or a
+// partial number matched by prefix when PhonePrefix is set.
+//
+// Filter is an optional expression in the filter language, and Sort an
+// optional comma-separated list of fields, descending when prefixed with a
+// minus, that replaces the listing's default order.
+//
+// Results are paged either by Page and Limit or, when Cursor is set, by
+// keyset from the cursor position. SkipTotal avoids counting all matches.
+type PeopleQuery struct {
+	Name           string
+	NamePart       string
+	Mode           string
+	Threshold      float64
+	Phone          string
+	PhonePrefix    bool
+	Page           int
+	Limit          int
+	IncludeDeleted bool
+	Sex            string
+	BornAfter      *time.Time
+	BornBefore     *time.Time
+	MinAge         *int
+	MaxAge         *int
+	Cursor         *Cursor
+	SkipTotal      bool
+	Sort           string
+	Filter         *filter.Expr
+}
+
+// Cursor is a keyset position in a people listing: the sort key values and id
+// of a row. Sort names the ordering the cursor was issued for, and Backward
+// asks for the rows before the position instead of after it.
+type Cursor struct {
+	Sort     string        `json:"s"`
+	Values   []interface{} `json:"v"`
+	ID       int           `json:"id"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+// PeoplePage is one page of a people listing. Total is nil when the count
+// was skipped; Next and Prev are nil at the ends of the listing.
+//
+// CacheStatus is set for searches whose pages are cached, to CacheHit or
+// CacheMiss; on a hit CachedAt tells when the page was loaded.
+type PeoplePage struct {
+	People      []Person
+	Total       *int
+	Next        *Cursor
+	Prev        *Cursor
+	CacheStatus string
+	CachedAt    time.Time
+}
+
+const (
+	CacheHit  = "HIT"
+	CacheMiss = "MISS"
+)
+
+// PersonSink consumes a stream of people. Begin is called once the query has
+// been accepted, before the first Write.
+type PersonSink interface {
+	Begin() error
+	Write(person *Person) error
 }
@@ -0,0 +1,100 @@
+package names
+
+import "strings"
+
+// Parts is a full name divided into surname, given name and patronymic.
+type Parts struct {
+	LastName   string
+	FirstName  string
+	MiddleName string
+}
+
+var patronymicSuffixes = []string{
+	"ович", "евич", "ьич", "овна", "евна", "ична", "улы", "ұлы", "уулу", "кызы", "қызы", "гызы",
+	"ovich", "evich", "ovna", "evna", "ichna", "uly", "kyzy", "qyzy",
+}
+
+// patronymicMarkers are Kazakh patronymic particles written as a separate word,
+// as in "Нурлан Серик улы".
+var patronymicMarkers = map[string]bool{
+	"улы": true, "ұлы": true, "кызы": true, "қызы": true, "uly": true, "kyzy": true, "qyzy": true,
+}
+
+var strongSurnameSuffixes = []string{
+	"ов", "ев", "ёв", "ова", "ева", "ёва", "ский", "ская", "цкий", "цкая", "енко", "швили", "дзе",
+	"ov", "ev", "ova", "eva", "skiy", "skaya", "sky", "enko",
+}
+
+var weakSurnameSuffixes = []string{"ин", "ина", "ын", "ына", "ук", "юк", "ян", "in", "ina", "yn", "uk", "yan"}
+
+// Split heuristically divides a free-text full name into its parts. It
+// recognises both "Surname Given Patronymic" and "Given [Patronymic] Surname"
+// orders; two-word names default to "Given Surname" unless the first word
+// looks more like a surname.
+func Split(full string) Parts {
+	words := strings.Fields(full)
+
+	for i := 1; i < len(words); i++ {
+		if patronymicMarkers[strings.ToLower(words[i])] {
+			words[i-1] = words[i-1] + " " + words[i]
+			words = append(words[:i], words[i+1:]...)
+			i--
+		}
+	}
+
+	switch len(words) {
+	case 0:
+		return Parts{}
+	case 1:
+		return Parts{FirstName: words[0]}
+	case 2:
+		if surnameScore(words[0]) > surnameScore(words[1]) {
+			return Parts{LastName: words[0], FirstName: words[1]}
+		}
+		return Parts{FirstName: words[0], LastName: words[1]}
+	case 3:
+		if isPatronymic(words[1]) && !isPatronymic(words[2]) {
+			return Parts{FirstName: words[0], MiddleName: words[1], LastName: words[2]}
+		}
+		if isPatronymic(words[2]) || surnameScore(words[0]) > surnameScore(words[2]) {
+			return Parts{LastName: words[0], FirstName: words[1], MiddleName: words[2]}
+		}
+		return Parts{FirstName: words[0], MiddleName: words[1], LastName: words[2]}
+	default:
+		last := len(words) - 1
+		if surnameScore(words[last]) > surnameScore(words[0]) {
+			return Parts{FirstName: words[0], MiddleName: strings.Join(words[1:last], " "), LastName: words[last]}
+		}
+		return Parts{LastName: words[0], FirstName: words[1], MiddleName: strings.Join(words[2:], " ")}
+	}
+}
+
+// Join builds the full name in the registry order "Surname Given Patronymic".
+func Join(parts Parts) string {
+	return strings.Join(strings.Fields(parts.LastName+" "+parts.FirstName+" "+parts.MiddleName), " ")
+}
+
+func isPatronymic(word string) bool {
+	return hasAnySuffix(strings.ToLower(word), patronymicSuffixes)
+}
+
+func surnameScore(word string) int {
+	word = strings.ToLower(word)
+	switch {
+	case hasAnySuffix(word, strongSurnameSuffixes):
+		return 2
+	case hasAnySuffix(word, weakSurnameSuffixes):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func hasAnySuffix(word string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) && len(word) > len(suffix) {
+			return true
+		}
+	}
+	return false
+}
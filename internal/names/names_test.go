@@ -0,0 +1,31 @@
+package names_test
+
+import (
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/names"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	tests := map[string]names.Parts{
+		"Dulat Nurmeden":           {FirstName: "Dulat", LastName: "Nurmeden"},
+		"Петрова Алина":            {LastName: "Петрова", FirstName: "Алина"},
+		"Алина Петрова":            {FirstName: "Алина", LastName: "Петрова"},
+		"Иванов Иван Иванович":     {LastName: "Иванов", FirstName: "Иван", MiddleName: "Иванович"},
+		"Иван Иванович Иванов":     {FirstName: "Иван", MiddleName: "Иванович", LastName: "Иванов"},
+		"Нурмеден Дулат Серикулы":  {LastName: "Нурмеден", FirstName: "Дулат", MiddleName: "Серикулы"},
+		"Нурмеден Дулат Серик улы": {LastName: "Нурмеден", FirstName: "Дулат", MiddleName: "Серик улы"},
+		"Dulat": {FirstName: "Dulat"},
+		"  ":    {},
+	}
+
+	for full, expected := range tests {
+		assert.Equal(t, expected, names.Split(full), full)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, "Иванов Иван Иванович", names.Join(names.Parts{LastName: "Иванов", FirstName: "Иван", MiddleName: "Иванович"}))
+	assert.Equal(t, "Nurmeden Dulat", names.Join(names.Parts{LastName: "Nurmeden", FirstName: "Dulat"}))
+}
@@ -0,0 +1,73 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+)
+
+// Codec turns cursors into opaque tokens and back. Tokens are the base64url
+// JSON cursor followed by its HMAC-SHA256, so clients cannot forge positions.
+type Codec struct {
+	secret []byte
+}
+
+// NewCodec returns a codec signing with secret. An empty secret is replaced by
+// a random one, in which case tokens do not survive a restart.
+func NewCodec(secret []byte) *Codec {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+	return &Codec{secret: secret}
+}
+
+// Encode returns the signed token for cursor, or an empty string for nil.
+func (c *Codec) Encode(cursor *models.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+// Decode verifies token and returns the cursor it encodes.
+func (c *Codec) Decode(token string) (*models.Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(payload)) {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	var cursor models.Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, errors.ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
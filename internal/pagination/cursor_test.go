@@ -0,0 +1,29 @@
+package pagination_test
+
+import (
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	codec := pagination.NewCodec([]byte("secret"))
+	cursor := &models.Cursor{Sort: "-score,name", Values: []interface{}{0.3333333432674408, "Dulat Nurmeden"}, ID: 42, Backward: true}
+
+	decoded, err := codec.Decode(codec.Encode(cursor))
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestCodecRejectsTamperedTokens(t *testing.T) {
+	codec := pagination.NewCodec([]byte("secret"))
+	token := codec.Encode(&models.Cursor{Sort: "phone,name", Values: []interface{}{"+77011234567", "Dulat"}, ID: 7})
+
+	forged := pagination.NewCodec([]byte("other")).Encode(&models.Cursor{Sort: "phone,name", Values: []interface{}{"+77011234567", "Dulat"}, ID: 7})
+	for _, bad := range []string{"", "garbage", token[:len(token)-2], "e30." + token[len(token)-43:], forged} {
+		_, err := codec.Decode(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,134 @@
+package phone
+
+import (
+	"strings"
+
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+)
+
+const (
+	TypeMobile   = "mobile"
+	TypeLandline = "landline"
+	TypeUnknown  = "unknown"
+)
+
+const (
+	OperatorKcell         = "Kcell"
+	OperatorBeeline       = "Beeline"
+	OperatorTele2Altel    = "Tele2/Altel"
+	OperatorKazakhtelecom = "Kazakhtelecom"
+)
+
+// mobileOperators maps Kazakh mobile DEF codes to their operators.
+var mobileOperators = map[string]string{
+	"700": OperatorTele2Altel,
+	"701": OperatorKcell,
+	"702": OperatorKcell,
+	"705": OperatorBeeline,
+	"706": OperatorBeeline,
+	"707": OperatorTele2Altel,
+	"708": OperatorTele2Altel,
+	"747": OperatorTele2Altel,
+	"750": OperatorKazakhtelecom,
+	"751": OperatorKazakhtelecom,
+	"760": OperatorKazakhtelecom,
+	"761": OperatorKazakhtelecom,
+	"762": OperatorKazakhtelecom,
+	"763": OperatorKazakhtelecom,
+	"764": OperatorKazakhtelecom,
+	"771": OperatorBeeline,
+	"775": OperatorKcell,
+	"776": OperatorBeeline,
+	"777": OperatorBeeline,
+	"778": OperatorKcell,
+}
+
+type Number struct {
+	E164     string `json:"e164"`
+	DEFCode  string `json:"def_code"`
+	Operator string `json:"operator,omitempty"`
+	Type     string `json:"type"`
+}
+
+// Parse accepts common Kazakh notations such as "+7 (701) 123-45-67",
+// "87011234567", "77011234567" and "7011234567" and normalizes them to E.164.
+func Parse(raw string) (*Number, error) {
+	national, international, err := extractDigits(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(national) == 11 && international:
+		if national[0] != '7' {
+			return nil, errors.ErrInvalidPhoneCountry
+		}
+		national = national[1:]
+	case len(national) == 11 && (national[0] == '7' || national[0] == '8'):
+		national = national[1:]
+	case len(national) == 10 && !international:
+	default:
+		return nil, errors.ErrInvalidPhoneLength
+	}
+
+	if national[0] != '7' {
+		return nil, errors.ErrInvalidPhoneCountry
+	}
+
+	number := &Number{E164: "+7" + national, DEFCode: national[:3], Type: TypeUnknown}
+	if operator, ok := mobileOperators[number.DEFCode]; ok {
+		number.Type = TypeMobile
+		number.Operator = operator
+	} else if national[1] == '1' || national[1] == '2' {
+		number.Type = TypeLandline
+		number.Operator = OperatorKazakhtelecom
+	}
+
+	return number, nil
+}
+
+// Prefixes returns the E.164 prefixes a partially typed number may correspond to.
+// Digits starting with 7 are ambiguous between a country code and a national
+// number, so both interpretations are returned.
+func Prefixes(partial string) ([]string, error) {
+	prefix, international, err := extractDigits(partial)
+	if err != nil {
+		return nil, err
+	}
+
+	if prefix == "" || len(prefix) > 11 {
+		return nil, errors.ErrInvalidPhoneLength
+	}
+
+	switch {
+	case international:
+		return []string{"+" + prefix}, nil
+	case prefix[0] == '8':
+		return []string{"+7" + prefix[1:]}, nil
+	case prefix[0] == '7' && len(prefix) <= 10:
+		return []string{"+" + prefix, "+7" + prefix}, nil
+	case prefix[0] == '7':
+		return []string{"+" + prefix}, nil
+	default:
+		return []string{"+7" + prefix}, nil
+	}
+}
+
+// extractDigits strips formatting characters and reports whether the number
+// started with a +.
+func extractDigits(raw string) (string, bool, error) {
+	var digits strings.Builder
+	international := false
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", false, errors.ErrInvalidPhoneFormat
+		}
+	}
+	return digits.String(), international, nil
+}
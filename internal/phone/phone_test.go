@@ -0,0 +1,66 @@
+package phone_test
+
+import (
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/phone"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw      string
+		e164     string
+		operator string
+		kind     string
+	}{
+		{"+7 (701) 123-45-67", "+77011234567", phone.OperatorKcell, phone.TypeMobile},
+		{"87011234567", "+77011234567", phone.OperatorKcell, phone.TypeMobile},
+		{"77011234567", "+77011234567", phone.OperatorKcell, phone.TypeMobile},
+		{"7771234567", "+77771234567", phone.OperatorBeeline, phone.TypeMobile},
+		{"8 747 123 45 67", "+77471234567", phone.OperatorTele2Altel, phone.TypeMobile},
+		{"+7 (727) 250-00-00", "+77272500000", phone.OperatorKazakhtelecom, phone.TypeLandline},
+	}
+
+	for _, tt := range tests {
+		number, err := phone.Parse(tt.raw)
+		if assert.NoError(t, err, tt.raw) {
+			assert.Equal(t, tt.e164, number.E164, tt.raw)
+			assert.Equal(t, tt.operator, number.Operator, tt.raw)
+			assert.Equal(t, tt.kind, number.Type, tt.raw)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]error{
+		"+7 701 123 45 6":    errors.ErrInvalidPhoneLength,
+		"7011234567a":        errors.ErrInvalidPhoneFormat,
+		"+7 (916) 123-45-67": errors.ErrInvalidPhoneCountry,
+		"":                   errors.ErrInvalidPhoneLength,
+	}
+
+	for raw, expected := range tests {
+		_, err := phone.Parse(raw)
+		assert.Equal(t, expected, err, raw)
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	tests := map[string][]string{
+		"+7 701":  {"+7701"},
+		"8701123": {"+7701123"},
+		"701":     {"+701", "+7701"},
+		"123":     {"+7123"},
+	}
+
+	for partial, expected := range tests {
+		prefixes, err := phone.Prefixes(partial)
+		assert.NoError(t, err, partial)
+		assert.Equal(t, expected, prefixes, partial)
+	}
+
+	_, err := phone.Prefixes("abc")
+	assert.Equal(t, errors.ErrInvalidPhoneFormat, err)
+}
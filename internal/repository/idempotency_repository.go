@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// maxClaimAttempts bounds how often Claim retries a Redis record that expires
+// between being found and being read.
+const maxClaimAttempts = 3
+
+// errClaimContended is returned by claimCached when the record kept expiring;
+// Redis itself is fine then.
+var errClaimContended = fmt.Errorf("idempotency key kept expiring while being claimed")
+
+// IdempotencyRepository remembers requests made with an Idempotency-Key.
+// Records live in Redis with a TTL; whenever Redis fails, or when there is no
+// Redis client at all, the operation falls back to the idempotency_keys
+// table, whose rows carry their own expiry. While the cache breaker is open
+// Redis is not tried at all, and a Redis failure opens it, so that an outage
+// does not cost every request a Redis timeout.
+//
+// A key stays in the store it was claimed in: Complete and Release follow
+// the claim, and Claim checks the table before trusting Redis, so a key that
+// ended up there while Redis was failing is not claimed a second time.
+type IdempotencyRepository struct {
+	DB     *sql.DB
+	Logger *logrus.Logger
+	Cache  *redis.Client
+	// Breaker is the breaker of the shared cache on the same Redis, if any.
+	Breaker *cache.Breaker
+
+	// cached holds the keys this process has claimed in Redis.
+	cached sync.Map
+}
+
+func NewIdempotencyRepository(db *sql.DB, logger *logrus.Logger, cache *redis.Client, breaker *cache.Breaker) *IdempotencyRepository {
+	return &IdempotencyRepository{DB: db, Logger: logger, Cache: cache, Breaker: breaker}
+}
+
+func idempotencyKey(key string) string {
+	return cache.Key("idempotency", key)
+}
+
+// Claim reserves key for a request with the given fingerprint. It returns nil
+// when the key was free and is now pending for lease, or the record already
+// stored under the key otherwise. Only Complete keeps the key for longer.
+func (r *IdempotencyRepository) Claim(ctx context.Context, key, fingerprint string, lease time.Duration) (*models.IdempotencyRecord, error) {
+	if !r.cacheUp() {
+		return r.claimStored(ctx, key, fingerprint, lease)
+	}
+
+	record, err := r.claimCached(ctx, key, fingerprint, lease)
+	if err != nil {
+		r.cacheFailed(ctx, err)
+		return r.claimStored(ctx, key, fingerprint, lease)
+	}
+	if record != nil && record.Completed() {
+		return record, nil
+	}
+
+	// The key may have been claimed in the table while Redis was failing, or
+	// completed there when Redis failed afterwards; the table then wins.
+	stored, err := r.findStored(ctx, key)
+	if err == nil && stored == nil {
+		if record == nil {
+			r.cached.Store(key, struct{}{})
+		}
+		return record, nil
+	}
+	if record == nil {
+		if err := r.Cache.Del(context.WithoutCancel(ctx), idempotencyKey(key)).Err(); err != nil {
+			r.Logger.WithError(err).Warn("Failed to drop idempotency claim from Redis")
+		}
+	}
+	return stored, err
+}
+
+// claimCached is Claim against Redis alone.
+func (r *IdempotencyRepository) claimCached(ctx context.Context, key, fingerprint string, lease time.Duration) (*models.IdempotencyRecord, error) {
+	data, err := json.Marshal(models.IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < maxClaimAttempts; attempt++ {
+		claimed, err := r.Cache.SetNX(ctx, idempotencyKey(key), data, lease).Result()
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return nil, nil
+		}
+		var record models.IdempotencyRecord
+		if err = r.getCached(ctx, key, &record); err != redis.Nil {
+			if err != nil {
+				return nil, err
+			}
+			return &record, nil
+		}
+		// The record expired between the two calls; try again.
+	}
+	return nil, errClaimContended
+}
+
+// Complete stores the response of the request holding key, keeping it for
+// ttl.
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, record models.IdempotencyRecord, ttl time.Duration) error {
+	if _, cached := r.cached.LoadAndDelete(key); cached && r.cacheUp() {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if err = r.Cache.Set(ctx, idempotencyKey(key), data, ttl).Err(); err == nil {
+			return nil
+		}
+		r.cacheFailed(ctx, err)
+	}
+
+	// A pending record left in Redis is overridden by the table on the next
+	// Claim.
+
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO idempotency_keys (key, fingerprint, status, content_type, body, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status = EXCLUDED.status,
+			content_type = EXCLUDED.content_type, body = EXCLUDED.body, expires_at = EXCLUDED.expires_at`,
+		key, record.Fingerprint, record.Status, record.ContentType, record.Body, time.Now().Add(ttl))
+	return err
+}
+
+// Release forgets a pending key so that the request may be retried, as is
+// done when it failed. A claim that cannot be dropped from Redis is left to
+// its lease.
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, cached := r.cached.LoadAndDelete(key); cached {
+		if !r.cacheUp() {
+			return nil
+		}
+		err := r.Cache.Del(ctx, idempotencyKey(key)).Err()
+		if err != nil {
+			r.cacheFailed(ctx, err)
+		}
+		return err
+	}
+
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status IS NULL`, key)
+	return err
+}
+
+// cacheUp reports whether Redis is worth trying: there is a client and the
+// breaker, if any, is closed.
+func (r *IdempotencyRepository) cacheUp() bool {
+	return r.Cache != nil && (r.Breaker == nil || r.Breaker.Health().State == cache.StateClosed)
+}
+
+// cacheFailed reports a Redis failure. Unless the caller gave up, it opens
+// the breaker, which probes Redis until it is back.
+func (r *IdempotencyRepository) cacheFailed(ctx context.Context, err error) {
+	r.Logger.WithError(err).Warn("Redis unavailable for idempotency keys, using database")
+	if r.Breaker != nil && ctx.Err() == nil && err != errClaimContended {
+		r.Breaker.Open(err)
+	}
+}
+
+func (r *IdempotencyRepository) getCached(ctx context.Context, key string, record *models.IdempotencyRecord) error {
+	data, err := r.Cache.Get(ctx, idempotencyKey(key)).Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, record)
+}
+
+// claimStored is Claim against the database. An expired row is taken over as
+// if the key were free, and a few other expired rows are cleared on the way.
+func (r *IdempotencyRepository) claimStored(ctx context.Context, key, fingerprint string, lease time.Duration) (*models.IdempotencyRecord, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now() AND (key = $1 OR key IN (
+		SELECT key FROM idempotency_keys WHERE expires_at <= now() LIMIT 100))`, key); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO idempotency_keys (key, fingerprint, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING`, key, fingerprint, time.Now().Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if inserted == 1 {
+		return nil, tx.Commit()
+	}
+
+	record, err := scanIdempotencyRecord(tx.QueryRowContext(ctx,
+		`SELECT fingerprint, status, content_type, body FROM idempotency_keys WHERE key = $1`, key))
+	if err != nil {
+		return nil, err
+	}
+	return record, tx.Commit()
+}
+
+// findStored returns the live row for key in the database, or nil if there
+// is none.
+func (r *IdempotencyRepository) findStored(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	record, err := scanIdempotencyRecord(r.DB.QueryRowContext(ctx,
+		`SELECT fingerprint, status, content_type, body FROM idempotency_keys WHERE key = $1 AND expires_at > now()`, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return record, err
+}
+
+func scanIdempotencyRecord(row *sql.Row) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var status sql.NullInt64
+	var contentType sql.NullString
+	if err := row.Scan(&record.Fingerprint, &status, &contentType, &record.Body); err != nil {
+		return nil, err
+	}
+	record.Status = int(status.Int64)
+	record.ContentType = contentType.String
+	return &record, nil
+}
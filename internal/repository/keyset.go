@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+)
+
+// sortField is a column people listings may be ordered by. value reads the
+// column back from a scanned row so that a cursor can resume after it.
+type sortField struct {
+	expr  string
+	value func(p *models.Person) interface{}
+}
+
+// sortFields lists the orderable columns. The score expression depends on
+// the query and is filled in by sortKeys.
+var sortFields = map[string]sortField{
+	"score":      {value: func(p *models.Person) interface{} { return p.Score }},
+	"name":       {expr: "name", value: func(p *models.Person) interface{} { return p.Name }},
+	"last_name":  {expr: "COALESCE(last_name, '')", value: func(p *models.Person) interface{} { return p.LastName }},
+	"first_name": {expr: "COALESCE(first_name, '')", value: func(p *models.Person) interface{} { return p.FirstName }},
+	"phone":      {expr: "phone", value: func(p *models.Person) interface{} { return p.Phone }},
+	"iin":        {expr: "iin", value: func(p *models.Person) interface{} { return p.IIN }},
+	"birth_date": {expr: "COALESCE(birth_date::text, '')", value: func(p *models.Person) interface{} { return p.BirthDate }},
+	"created_at": {expr: "created_at", value: func(p *models.Person) interface{} { return p.CreatedAt }},
+}
+
+// sortKey is one ORDER BY term. Listings always end with id as a tie-breaker,
+// which keeps the ordering total and keyset positions unambiguous.
+type sortKey struct {
+	sortField
+	desc bool
+}
+
+// sortKeys parses a sort spec such as "-created_at,name", where a leading
+// minus means descending order. Only fields in sortFields are accepted, and
+// score only when the listing has a relevance score.
+func sortKeys(spec string, score string) ([]sortKey, error) {
+	var keys []sortKey
+	seen := map[string]bool{}
+	for _, name := range strings.Split(spec, ",") {
+		key := sortKey{desc: strings.HasPrefix(name, "-")}
+		name = strings.TrimPrefix(name, "-")
+
+		field, ok := sortFields[name]
+		if !ok || seen[name] || (name == "score" && score == "") {
+			return nil, invalidSort(name)
+		}
+		seen[name] = true
+
+		key.sortField = field
+		if key.expr == "" {
+			key.expr = score
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func invalidSort(field string) error {
+	sortable := make([]string, 0, len(sortFields))
+	for name := range sortFields {
+		sortable = append(sortable, name)
+	}
+	sort.Strings(sortable)
+
+	return &errors.AppError{
+		Code:    http.StatusBadRequest,
+		Message: fmt.Sprintf("Cannot sort by %q or sort by it twice, expected a comma-separated list of %s", field, strings.Join(sortable, ", ")),
+		Field:   "sort",
+	}
+}
+
+// orderBy renders the keys as an ORDER BY list, reversed when backward.
+func orderBy(keys []sortKey, backward bool) string {
+	terms := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		terms = append(terms, key.expr+direction(key.desc != backward))
+	}
+	return strings.Join(append(terms, "id"+direction(backward)), ", ")
+}
+
+func direction(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
+
+// keysetCondition selects the rows strictly after the cursor position in the
+// ordering, or strictly before it for a backward cursor. Mixed directions
+// rule out a row-value comparison, so the condition is expanded into
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... ending with the id.
+func keysetCondition(where *whereBuilder, keys []sortKey, cursor *models.Cursor) string {
+	var terms, equal []string
+	for i, key := range keys {
+		value := where.arg(cursor.Values[i])
+		terms = append(terms, strings.Join(append(equal, key.expr+comparison(key.desc, cursor.Backward)+value), " AND "))
+		equal = append(equal, key.expr+" = "+value)
+	}
+	terms = append(terms, strings.Join(append(equal, "id"+comparison(false, cursor.Backward)+where.arg(cursor.ID)), " AND "))
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
+
+func comparison(desc, backward bool) string {
+	if desc != backward {
+		return " < "
+	}
+	return " > "
+}
+
+// checkCursor rejects cursors issued for another ordering.
+func checkCursor(cursor *models.Cursor, spec string, keys []sortKey) error {
+	if cursor.Sort != spec || len(cursor.Values) != len(keys) {
+		return errors.ErrInvalidCursor
+	}
+	for _, value := range cursor.Values {
+		if value == nil {
+			return errors.ErrInvalidCursor
+		}
+	}
+	return nil
+}
+
+// cursorAt returns the position of person in the ordering.
+func cursorAt(person *models.Person, spec string, keys []sortKey, backward bool) *models.Cursor {
+	cursor := &models.Cursor{Sort: spec, ID: person.ID, Backward: backward}
+	for _, key := range keys {
+		cursor.Values = append(cursor.Values, key.value(person))
+	}
+	return cursor
+}
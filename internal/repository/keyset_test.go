@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	keys, err := sortKeys("-score,name", "similarity")
+	assert.NoError(t, err)
+	assert.Equal(t, "similarity DESC, name ASC, id ASC", orderBy(keys, false))
+	assert.Equal(t, "similarity ASC, name DESC, id DESC", orderBy(keys, true))
+
+	where := &whereBuilder{}
+	cursor := &models.Cursor{Sort: "-score,name", Values: []interface{}{0.5, "Dulat"}, ID: 7}
+	assert.Equal(t,
+		"(similarity < $1 OR similarity = $1 AND name > $2 OR similarity = $1 AND name = $2 AND id > $3)",
+		keysetCondition(where, keys, cursor))
+	assert.Equal(t, []interface{}{0.5, "Dulat", 7}, where.args)
+
+	cursor.Backward = true
+	assert.Equal(t,
+		"(similarity > $4 OR similarity = $4 AND name < $5 OR similarity = $4 AND name = $5 AND id < $6)",
+		keysetCondition(where, keys, cursor))
+}
+
+func TestCheckCursor(t *testing.T) {
+	keys, err := sortKeys("phone,name", "")
+	assert.NoError(t, err)
+	assert.NoError(t, checkCursor(&models.Cursor{Sort: "phone,name", Values: []interface{}{"+77011234567", "Dulat"}}, "phone,name", keys))
+	assert.Error(t, checkCursor(&models.Cursor{Sort: "-score,name", Values: []interface{}{0.5, "Dulat"}}, "phone,name", keys))
+	assert.Error(t, checkCursor(&models.Cursor{Sort: "phone,name", Values: []interface{}{"+77011234567"}}, "phone,name", keys))
+}
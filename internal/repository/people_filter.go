@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/filter"
+	"github.com/ddProgerGo/task-kaspi/internal/phone"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+)
+
+// filterFields lists the fields the filter language may reference. Each one
+// translates a value into a parameterized condition, or reports why the
+// value is unusable.
+var filterFields = map[string]func(where *whereBuilder, value string) (string, error){
+	"phone_prefix":    phonePrefixCondition,
+	"iin_prefix":      iinPrefixCondition,
+	"created_between": createdBetweenCondition,
+}
+
+// filterCondition translates a parsed filter expression into SQL.
+func filterCondition(where *whereBuilder, expr filter.Expr) (string, error) {
+	if expr.Op == "" {
+		condition, ok := filterFields[expr.Field]
+		if !ok {
+			return "", invalidFilter("unknown field %q, expected one of %s", expr.Field, strings.Join(filterFieldNames(), ", "))
+		}
+		return condition(where, expr.Value)
+	}
+
+	terms := make([]string, 0, len(expr.Terms))
+	for _, term := range expr.Terms {
+		cond, err := filterCondition(where, term)
+		if err != nil {
+			return "", err
+		}
+		terms = append(terms, cond)
+	}
+	return "(" + strings.Join(terms, " "+expr.Op+" ") + ")", nil
+}
+
+func phonePrefixCondition(where *whereBuilder, value string) (string, error) {
+	prefixes, err := phone.Prefixes(value)
+	if err != nil {
+		return "", invalidFilter("phone_prefix %q: %s", value, err.Error())
+	}
+
+	conds := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		conds = append(conds, `phone LIKE `+where.arg(escapeLike(prefix)+"%"))
+	}
+	return `(` + strings.Join(conds, ` OR `) + `)`, nil
+}
+
+func iinPrefixCondition(where *whereBuilder, value string) (string, error) {
+	if value == "" || len(value) > 12 || strings.Trim(value, "0123456789") != "" {
+		return "", invalidFilter("iin_prefix %q must be 1 to 12 digits", value)
+	}
+	return `iin LIKE ` + where.arg(value+"%"), nil
+}
+
+// createdBetweenCondition matches a from..to range of dates (YYYY-MM-DD) or
+// RFC 3339 timestamps; either end may be omitted. A date as the upper bound
+// includes the whole day.
+func createdBetweenCondition(where *whereBuilder, value string) (string, error) {
+	from, to, ok := strings.Cut(value, "..")
+	if !ok || (from == "" && to == "") {
+		return "", invalidFilter("created_between %q, expected from..to", value)
+	}
+
+	var conds []string
+	if from != "" {
+		start, _, err := parseFilterTime(from)
+		if err != nil {
+			return "", invalidFilter("created_between %q: invalid start %q", value, from)
+		}
+		conds = append(conds, `created_at >= `+where.arg(start))
+	}
+	if to != "" {
+		end, dateOnly, err := parseFilterTime(to)
+		if err != nil {
+			return "", invalidFilter("created_between %q: invalid end %q", value, to)
+		}
+		if dateOnly {
+			conds = append(conds, `created_at < `+where.arg(end.AddDate(0, 0, 1)))
+		} else {
+			conds = append(conds, `created_at <= `+where.arg(end))
+		}
+	}
+	return `(` + strings.Join(conds, ` AND `) + `)`, nil
+}
+
+func parseFilterTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+func filterFieldNames() []string {
+	fields := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func invalidFilter(format string, args ...interface{}) error {
+	return &errors.AppError{Code: http.StatusBadRequest, Message: "Invalid filter: " + fmt.Sprintf(format, args...), Field: "filter"}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterCondition(t *testing.T) {
+	expr, err := filter.Parse(`phone_prefix:8701 or (iin_prefix:0203 and created_between:2024-01-01..2024-12-31)`)
+	assert.NoError(t, err)
+
+	where := &whereBuilder{}
+	cond, err := filterCondition(where, expr)
+	assert.NoError(t, err)
+	assert.Equal(t, "((phone LIKE $1) OR (iin LIKE $2 AND (created_at >= $3 AND created_at < $4)))", cond)
+	assert.Equal(t, []interface{}{
+		"+7701%",
+		"0203%",
+		time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}, where.args)
+}
+
+func TestFilterConditionRejectsUnknownFieldsAndValues(t *testing.T) {
+	for _, input := range []string{
+		`name:Dulat`,
+		`iin_prefix:02a`,
+		`iin_prefix:0203040506070`,
+		`phone_prefix:abc`,
+		`created_between:2024-01-01`,
+		`created_between:..`,
+		`created_between:yesterday..`,
+	} {
+		expr, err := filter.Parse(input)
+		assert.NoError(t, err, input)
+
+		_, err = filterCondition(&whereBuilder{}, expr)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestSortKeysWhitelist(t *testing.T) {
+	keys, err := sortKeys("-created_at,name", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "created_at DESC, name ASC, id ASC", orderBy(keys, false))
+
+	for _, spec := range []string{"deleted_by", "name,-name", "score", "name;DROP TABLE people", ""} {
+		_, err := sortKeys(spec, "")
+		assert.Error(t, err, spec)
+	}
+}
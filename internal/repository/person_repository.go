@@ -3,46 +3,141 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"fmt"
-	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
 	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/names"
+	"github.com/ddProgerGo/task-kaspi/internal/phone"
+	"github.com/ddProgerGo/task-kaspi/internal/translit"
 	"github.com/ddProgerGo/task-kaspi/pkg/errors"
-	"github.com/go-redis/redis/v8"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
+const personColumns = `id, name, COALESCE(last_name, ''), COALESCE(first_name, ''), COALESCE(middle_name, ''),
+	iin, phone, COALESCE(birth_date::text, ''), COALESCE(sex, ''), created_at, deleted_at, deleted_by`
+
 type PersonRepository struct {
 	DB     *sql.DB
 	Logger *logrus.Logger
-	Cache  *redis.Client
+	Cache  cache.Cache
 }
 
-func NewPersonRepository(db *sql.DB, logger *logrus.Logger, cache *redis.Client) *PersonRepository {
+func NewPersonRepository(db *sql.DB, logger *logrus.Logger, cache cache.Cache) *PersonRepository {
 	return &PersonRepository{DB: db, Logger: logger, Cache: cache}
 }
 
-func (r *PersonRepository) SavePerson(person models.Person) error {
-	query := `INSERT INTO people (name, iin, phone) VALUES ($1, $2, $3) RETURNING id`
-	err := r.DB.QueryRow(query, person.Name, person.IIN, person.Phone).Scan(&person.ID)
-	if err != nil {
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPerson(row rowScanner, person *models.Person, extra ...interface{}) error {
+	dest := []interface{}{&person.ID, &person.Name, &person.LastName, &person.FirstName, &person.MiddleName,
+		&person.IIN, &person.Phone, &person.BirthDate, &person.Sex, &person.CreatedAt, &person.DeletedAt, &person.DeletedBy}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return err
 	}
 
-	cacheKey := fmt.Sprintf("person:%s", person.IIN)
-	if err := r.Cache.Del(context.Background(), cacheKey).Err(); err != nil {
-		log.Printf("Ошибка очистки кэша для IIN %s: %v", person.IIN, err)
+	if number, err := phone.Parse(person.Phone); err == nil {
+		person.PhoneOperator = number.Operator
+		person.PhoneType = number.Type
 	}
-
 	return nil
 }
 
-func (r *PersonRepository) GetPersonByIIN(iin string) (*models.Person, error) {
-	query := `SELECT id, name, iin, phone FROM people WHERE iin = $1`
-	row := r.DB.QueryRow(query, iin)
+// nameColumns are the name-derived columns written by nameValues.
+const nameColumns = `name_search_key, last_name, first_name, middle_name, last_name_key, first_name_key, middle_name_key`
+
+func nameValues(person models.Person) []interface{} {
+	return []interface{}{
+		translit.SearchKey(person.Name),
+		person.LastName,
+		person.FirstName,
+		person.MiddleName,
+		translit.SearchKey(person.LastName),
+		translit.SearchKey(person.FirstName),
+		translit.SearchKey(person.MiddleName),
+	}
+}
+
+// SavePerson inserts a person. When the IIN is already stored, onConflict
+// decides the outcome: OnConflictError fails with ErrDuplicateIIN carrying the
+// stored person's id, OnConflictIgnore keeps the stored person and
+// OnConflictUpdate overwrites it. A soft-deleted person is never overwritten
+// and is reported as a duplicate instead.
+func (r *PersonRepository) SavePerson(ctx context.Context, person models.Person, onConflict string) (*models.SaveResult, error) {
+	query := `INSERT INTO people (name, iin, phone, birth_date, sex, ` + nameColumns + `)
+		VALUES ($1, $2, $3, NULLIF($4, '')::date, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12)`
+	switch onConflict {
+	case models.OnConflictIgnore:
+		query += ` ON CONFLICT (iin) DO NOTHING`
+	case models.OnConflictUpdate:
+		query += ` ON CONFLICT (iin) DO UPDATE SET name = EXCLUDED.name, phone = EXCLUDED.phone,
+			birth_date = EXCLUDED.birth_date, sex = EXCLUDED.sex,
+			(` + nameColumns + `) = (EXCLUDED.name_search_key, EXCLUDED.last_name, EXCLUDED.first_name,
+				EXCLUDED.middle_name, EXCLUDED.last_name_key, EXCLUDED.first_name_key, EXCLUDED.middle_name_key)
+			WHERE people.deleted_at IS NULL`
+	}
+	// xmax is zero for a freshly inserted row and set for an updated one.
+	query += ` RETURNING id, xmax = 0`
+
+	args := append([]interface{}{person.Name, person.IIN, person.Phone, person.BirthDate, person.Sex}, nameValues(person)...)
+	result := &models.SaveResult{Status: models.SaveCreated}
+	var inserted bool
+	err := r.DB.QueryRowContext(ctx, query, args...).Scan(&result.ID, &inserted)
+	if err == sql.ErrNoRows {
+		// Nothing was written: the IIN is taken, by a deleted person when
+		// updating.
+		id, lookupErr := r.personID(ctx, person.IIN)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		if onConflict == models.OnConflictIgnore {
+			return &models.SaveResult{ID: id, Status: models.SaveIgnored}, nil
+		}
+		return nil, errors.ErrDuplicateIIN.WithID(id)
+	}
+	if err != nil {
+		err = translateError(err)
+		if err == errors.ErrDuplicateIIN {
+			id, lookupErr := r.personID(ctx, person.IIN)
+			if lookupErr != nil {
+				return nil, lookupErr
+			}
+			return nil, errors.ErrDuplicateIIN.WithID(id)
+		}
+		return nil, err
+	}
+	if !inserted {
+		result.Status = models.SaveUpdated
+	}
+
+	if err := r.Cache.Delete(context.WithoutCancel(ctx), cache.PersonKey(person.IIN)); err != nil && err != cache.ErrUnavailable {
+		r.Logger.WithError(err).Error("Failed to invalidate cached person: ", person.IIN)
+	}
+
+	return result, nil
+}
+
+// personID returns the id of the person with the given IIN, deleted or not.
+func (r *PersonRepository) personID(ctx context.Context, iin string) (int, error) {
+	var id int
+	if err := r.DB.QueryRowContext(ctx, `SELECT id FROM people WHERE iin = $1`, iin).Scan(&id); err != nil {
+		r.Logger.WithError(err).Error("Failed to look up person id")
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *PersonRepository) GetPersonByIIN(ctx context.Context, iin string, includeDeleted bool) (*models.Person, error) {
+	query := `SELECT ` + personColumns + ` FROM people WHERE iin = $1 AND ($2 OR deleted_at IS NULL)`
+	row := r.DB.QueryRowContext(ctx, query, iin, includeDeleted)
 
 	var person models.Person
-	if err := row.Scan(&person.ID, &person.Name, &person.IIN, &person.Phone); err != nil {
+	if err := scanPerson(row, &person); err != nil {
 		if err == sql.ErrNoRows {
 			r.Logger.Warn("Person not found with IIN: ", iin)
 			return nil, errors.ErrNotFound
@@ -55,38 +150,487 @@ func (r *PersonRepository) GetPersonByIIN(iin string) (*models.Person, error) {
 	return &person, nil
 }
 
-func (r *PersonRepository) GetPeopleByName(namePart string, page int, limit int) ([]models.Person, int, error) {
-	offset := (page - 1) * limit
+func (r *PersonRepository) ListPeople(ctx context.Context, q models.PeopleQuery) (*models.PeoplePage, error) {
+	return r.listPeople(ctx, q, "name")
+}
+
+func (r *PersonRepository) GetPeopleByName(ctx context.Context, q models.PeopleQuery) (*models.PeoplePage, error) {
+	return r.listPeople(ctx, q, "-score,name")
+}
+
+func (r *PersonRepository) GetPeopleByPhone(ctx context.Context, q models.PeopleQuery) (*models.PeoplePage, error) {
+	return r.listPeople(ctx, q, "phone,name")
+}
+
+// listPeople returns one page of the people matching q in the order given by
+// q.Sort or else defaultSort, either at the page offset or after q.Cursor.
+func (r *PersonRepository) listPeople(ctx context.Context, q models.PeopleQuery, defaultSort string) (*models.PeoplePage, error) {
+	where, err := peopleWhere(q)
+	if err != nil {
+		return nil, err
+	}
+
+	sort := defaultSort
+	if q.Sort != "" {
+		sort = q.Sort
+	}
+
+	// The count is taken before the score and cursor arguments are registered
+	// so that it receives only the arguments it references.
+	countQuery := `SELECT COUNT(*) FROM people WHERE ` + where.String()
+	countArgs := append([]interface{}(nil), where.args...)
+
+	score := peopleScore(where, q)
+	keys, err := sortKeys(sort, score)
+	if err != nil {
+		return nil, err
+	}
+
+	backward := false
+	pagination := ` LIMIT ` + where.arg(q.Limit+1)
+	if q.Cursor != nil {
+		if err := checkCursor(q.Cursor, sort, keys); err != nil {
+			return nil, err
+		}
+		backward = q.Cursor.Backward
+		where.add(keysetCondition(where, keys, q.Cursor))
+	} else {
+		pagination += ` OFFSET ` + where.arg((q.Page-1)*q.Limit)
+	}
+
+	if score == "" {
+		score = `NULL::float8`
+	}
+	query := `SELECT ` + personColumns + `, ` + score + ` AS score FROM people WHERE ` + where.String() +
+		` ORDER BY ` + orderBy(keys, backward) + pagination
+
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		r.Logger.WithError(err).Error("Failed to begin people search transaction")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if q.Name != "" && q.Mode == models.SearchModeFuzzy {
+		threshold := strconv.FormatFloat(q.Threshold, 'f', -1, 64)
+		if _, err := tx.ExecContext(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, threshold); err != nil {
+			r.Logger.WithError(err).Error("Failed to set similarity threshold")
+			return nil, err
+		}
+	}
 
-	var total int
-	countQuery := `SELECT COUNT(*) FROM people WHERE name ILIKE $1`
-	if err := r.DB.QueryRow(countQuery, "%"+namePart+"%").Scan(&total); err != nil {
-		r.Logger.WithError(err).Error("Failed to get total count of people")
-		return nil, 0, err
+	page := &models.PeoplePage{}
+	if !q.SkipTotal {
+		var total int
+		if err := tx.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			r.Logger.WithError(err).Error("Failed to get total count of people")
+			return nil, err
+		}
+		page.Total = &total
 	}
 
-	query := `SELECT id, name, iin, phone FROM people WHERE name ILIKE $1 ORDER BY name ASC LIMIT $2 OFFSET $3`
-	rows, err := r.DB.Query(query, "%"+namePart+"%", limit, offset)
+	rows, err := tx.QueryContext(ctx, query, where.args...)
 	if err != nil {
 		r.Logger.WithError(err).Error("Failed to execute query for people search")
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
-	var people []models.Person
 	for rows.Next() {
 		var person models.Person
-		if err := rows.Scan(&person.ID, &person.Name, &person.IIN, &person.Phone); err != nil {
+		if err := scanPerson(rows, &person, &person.Score); err != nil {
 			r.Logger.WithError(err).Error("Failed to scan person row")
-			return nil, 0, err
+			return nil, err
 		}
-		people = append(people, person)
+		page.People = append(page.People, person)
 	}
 
 	if err := rows.Err(); err != nil {
 		r.Logger.WithError(err).Error("Error iterating through person rows")
-		return nil, 0, err
+		return nil, err
+	}
+
+	// One extra row was fetched to tell whether the listing continues past
+	// this page in the direction of travel.
+	more := len(page.People) > q.Limit
+	if more {
+		page.People = page.People[:q.Limit]
+	}
+	if backward {
+		for i, j := 0, len(page.People)-1; i < j; i, j = i+1, j-1 {
+			page.People[i], page.People[j] = page.People[j], page.People[i]
+		}
+	}
+
+	if n := len(page.People); n > 0 {
+		if more || backward {
+			page.Next = cursorAt(&page.People[n-1], sort, keys, false)
+		}
+		if (more && backward) || (!backward && (q.Cursor != nil || q.Page > 1)) {
+			page.Prev = cursorAt(&page.People[0], sort, keys, true)
+		}
+	}
+
+	return page, nil
+}
+
+// nameKeyColumn returns the search key column for the targeted name part.
+func nameKeyColumn(q models.PeopleQuery) string {
+	switch q.NamePart {
+	case models.NamePartLast:
+		return `last_name_key`
+	case models.NamePartFirst:
+		return `first_name_key`
+	case models.NamePartMiddle:
+		return `middle_name_key`
+	default:
+		return `name_search_key`
+	}
+}
+
+// peopleScore returns the relevance expression for name searches, or an
+// empty string for listings that are not ranked.
+func peopleScore(where *whereBuilder, q models.PeopleQuery) string {
+	if q.Name == "" {
+		return ``
+	}
+	return `word_similarity(` + where.arg(translit.SearchKey(q.Name)) + `, ` + nameKeyColumn(q) + `)`
+}
+
+// peopleWhere translates the search query into a parameterized WHERE clause.
+func peopleWhere(q models.PeopleQuery) (*whereBuilder, error) {
+	where := &whereBuilder{}
+
+	if q.Name != "" {
+		key := translit.SearchKey(q.Name)
+		column := nameKeyColumn(q)
+		switch q.Mode {
+		case models.SearchModeExact:
+			where.add(column + ` = ` + where.arg(key))
+		case models.SearchModePrefix:
+			where.add(column + ` LIKE ` + where.arg(escapeLike(key)+"%"))
+		case models.SearchModeFuzzy:
+			where.add(where.arg(key) + ` <% ` + column)
+		default:
+			where.add(column + ` LIKE ` + where.arg("%"+escapeLike(key)+"%"))
+		}
+	}
+	if q.Phone != "" && !q.PhonePrefix {
+		where.add(`phone = ` + where.arg(q.Phone))
+	}
+	if q.Phone != "" && q.PhonePrefix {
+		prefixes, _ := phone.Prefixes(q.Phone)
+		conds := make([]string, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			conds = append(conds, `phone LIKE `+where.arg(escapeLike(prefix)+"%"))
+		}
+		where.add(`(` + strings.Join(conds, ` OR `) + `)`)
+	}
+	if !q.IncludeDeleted {
+		where.add(`deleted_at IS NULL`)
+	}
+	if q.Sex != "" {
+		where.add(`sex = ` + where.arg(q.Sex))
+	}
+	if q.BornAfter != nil {
+		where.add(`birth_date > ` + where.arg(*q.BornAfter))
+	}
+	if q.BornBefore != nil {
+		where.add(`birth_date < ` + where.arg(*q.BornBefore))
+	}
+	if q.MinAge != nil {
+		where.add(`birth_date <= CURRENT_DATE - make_interval(years => ` + where.arg(*q.MinAge) + `)`)
+	}
+	if q.MaxAge != nil {
+		where.add(`birth_date > CURRENT_DATE - make_interval(years => ` + where.arg(*q.MaxAge+1) + `)`)
+	}
+	if q.Filter != nil {
+		cond, err := filterCondition(where, *q.Filter)
+		if err != nil {
+			return nil, err
+		}
+		where.add(cond)
+	}
+
+	return where, nil
+}
+
+func (r *PersonRepository) UpdatePerson(ctx context.Context, iin string, person models.Person) (*models.Person, error) {
+	query := `UPDATE people SET name = $1, iin = $2, phone = $3, birth_date = NULLIF($4, '')::date, sex = NULLIF($5, ''),
+		(` + nameColumns + `) = ($6, $7, $8, $9, $10, $11, $12)
+		WHERE iin = $13 AND deleted_at IS NULL RETURNING id`
+	args := append([]interface{}{person.Name, person.IIN, person.Phone, person.BirthDate, person.Sex}, nameValues(person)...)
+	err := r.DB.QueryRowContext(ctx, query, append(args, iin)...).Scan(&person.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Warn("Person not found for update with IIN: ", iin)
+			return nil, errors.ErrNotFound
+		}
+		r.Logger.WithError(err).Error("Failed to update person")
+		return nil, translateError(err)
+	}
+
+	r.Logger.Info("Person updated successfully with IIN: ", iin)
+	return &person, nil
+}
+
+func (r *PersonRepository) DeletePerson(ctx context.Context, iin string, deletedBy string) error {
+	query := `UPDATE people SET deleted_at = NOW(), deleted_by = NULLIF($2, '') WHERE iin = $1 AND deleted_at IS NULL`
+	result, err := r.DB.ExecContext(ctx, query, iin, deletedBy)
+	if err != nil {
+		r.Logger.WithError(err).Error("Failed to delete person")
+		return err
 	}
 
-	return people, total, nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.WithError(err).Error("Failed to get affected rows for delete")
+		return err
+	}
+
+	if affected == 0 {
+		r.Logger.Warn("Person not found for delete with IIN: ", iin)
+		return errors.ErrNotFound
+	}
+
+	r.Logger.Info("Person soft-deleted successfully with IIN: ", iin)
+	return nil
+}
+
+func (r *PersonRepository) RestorePerson(ctx context.Context, iin string) (*models.Person, error) {
+	query := `UPDATE people SET deleted_at = NULL, deleted_by = NULL WHERE iin = $1 AND deleted_at IS NOT NULL RETURNING ` + personColumns
+	row := r.DB.QueryRowContext(ctx, query, iin)
+
+	var person models.Person
+	if err := scanPerson(row, &person); err != nil {
+		if err == sql.ErrNoRows {
+			r.Logger.Warn("Deleted person not found for restore with IIN: ", iin)
+			return nil, errors.ErrNotFound
+		}
+		r.Logger.WithError(err).Error("Failed to restore person")
+		return nil, err
+	}
+
+	r.Logger.Info("Person restored successfully with IIN: ", iin)
+	return &person, nil
+}
+
+func (r *PersonRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM people WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+	result, err := r.DB.ExecContext(ctx, query, before)
+	if err != nil {
+		r.Logger.WithError(err).Error("Failed to purge deleted people")
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		r.Logger.WithError(err).Error("Failed to get affected rows for purge")
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// BackfillNameFields computes the search key and name parts for rows saved
+// before those columns existed. It returns the number of rows updated.
+func (r *PersonRepository) BackfillNameFields(ctx context.Context, batchSize int) (int, error) {
+	total := 0
+	for {
+		rows, err := r.DB.QueryContext(ctx, `SELECT id, name FROM people
+			WHERE name_search_key IS NULL OR first_name IS NULL ORDER BY id LIMIT $1`, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		var ids []int64
+		columns := make([][]string, 7)
+		for rows.Next() {
+			var id int64
+			var person models.Person
+			if err := rows.Scan(&id, &person.Name); err != nil {
+				rows.Close()
+				return total, err
+			}
+
+			parts := names.Split(person.Name)
+			person.LastName, person.FirstName, person.MiddleName = parts.LastName, parts.FirstName, parts.MiddleName
+
+			ids = append(ids, id)
+			for i, value := range nameValues(person) {
+				columns[i] = append(columns[i], value.(string))
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, err
+		}
+
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		query := `UPDATE people SET (` + nameColumns + `) = (v.search_key, v.last_name, v.first_name, v.middle_name, v.last_key, v.first_key, v.middle_key)
+			FROM unnest($1::bigint[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[])
+				AS v(id, search_key, last_name, first_name, middle_name, last_key, first_key, middle_key)
+			WHERE people.id = v.id`
+		args := []interface{}{pq.Array(ids)}
+		for _, column := range columns {
+			args = append(args, pq.Array(column))
+		}
+		if _, err := r.DB.ExecContext(ctx, query, args...); err != nil {
+			return total, err
+		}
+
+		total += len(ids)
+		r.Logger.Info("Backfilled name fields: ", total)
+	}
+}
+
+// ImportPeople inserts people in a single transaction. The rows are loaded
+// with COPY into a temporary table and moved into people skipping IINs that
+// already exist. It returns the set of IINs actually inserted.
+func (r *PersonRepository) ImportPeople(ctx context.Context, people []models.Person) (map[string]bool, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	columns := `name, iin, phone, birth_date, sex, ` + nameColumns
+	if _, err := tx.ExecContext(ctx, `CREATE TEMP TABLE people_import ON COMMIT DROP AS SELECT `+columns+` FROM people WITH NO DATA`); err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("people_import", strings.Split(strings.ReplaceAll(columns, " ", ""), ",")...))
+	if err != nil {
+		return nil, err
+	}
+	for _, person := range people {
+		args := append([]interface{}{person.Name, person.IIN, person.Phone, person.BirthDate, person.Sex}, nameValues(person)...)
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			stmt.Close()
+			return nil, err
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return nil, err
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `INSERT INTO people (`+columns+`) SELECT `+columns+` FROM people_import
+		ON CONFLICT (iin) DO NOTHING RETURNING iin`)
+	if err != nil {
+		return nil, err
+	}
+	inserted := make(map[string]bool, len(people))
+	for rows.Next() {
+		var iin string
+		if err := rows.Scan(&iin); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		inserted[iin] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return inserted, tx.Commit()
+}
+
+// ExistingIINs reports which of the IINs are already stored, soft-deleted
+// people included since they still hold their IIN.
+func (r *PersonRepository) ExistingIINs(ctx context.Context, iins []string) (map[string]bool, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT iin FROM people WHERE iin = ANY($1)`, pq.Array(iins))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool, len(iins))
+	for rows.Next() {
+		var iin string
+		if err := rows.Scan(&iin); err != nil {
+			return nil, err
+		}
+		existing[iin] = true
+	}
+	return existing, rows.Err()
+}
+
+// exportFetchSize is the number of rows fetched per round trip when exporting.
+const exportFetchSize = 1000
+
+// ExportPeople streams the people matching q to sink in q.Sort order, or by
+// id when no sort is given. Rows are read through a server-side cursor, so
+// memory use does not grow with the number of rows. Paging fields of q are
+// ignored.
+func (r *PersonRepository) ExportPeople(ctx context.Context, q models.PeopleQuery, sink models.PersonSink) error {
+	where, err := peopleWhere(q)
+	if err != nil {
+		return err
+	}
+
+	order := `id`
+	if q.Sort != "" {
+		keys, err := sortKeys(q.Sort, "")
+		if err != nil {
+			return err
+		}
+		order = orderBy(keys, false)
+	}
+
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		r.Logger.WithError(err).Error("Failed to begin people export transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	declare := `DECLARE people_export NO SCROLL CURSOR FOR SELECT ` + personColumns +
+		` FROM people WHERE ` + where.String() + ` ORDER BY ` + order
+	if _, err := tx.ExecContext(ctx, declare, where.args...); err != nil {
+		r.Logger.WithError(err).Error("Failed to declare people export cursor")
+		return err
+	}
+
+	if err := sink.Begin(); err != nil {
+		return err
+	}
+
+	fetch := `FETCH ` + strconv.Itoa(exportFetchSize) + ` FROM people_export`
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			r.Logger.WithError(err).Error("Failed to fetch exported people")
+			return err
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var person models.Person
+			if err := scanPerson(rows, &person); err != nil {
+				rows.Close()
+				return err
+			}
+			if err := sink.Write(&person); err != nil {
+				rows.Close()
+				return err
+			}
+			fetched++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if fetched < exportFetchSize {
+			return tx.Commit()
+		}
+	}
 }
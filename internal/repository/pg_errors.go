@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"net/http"
+
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/lib/pq"
+)
+
+// Postgres error codes translated by translateError.
+const (
+	pgNotNullViolation = "23502"
+	pgUniqueViolation  = "23505"
+	pgCheckViolation   = "23514"
+)
+
+// constraintFields maps the constraints on people to the field they guard.
+var constraintFields = map[string]string{
+	"people_iin_key":     "iin",
+	"people_iin_check":   "iin",
+	"people_phone_check": "phone",
+	"people_sex_check":   "sex",
+}
+
+// translateError turns constraint violations reported by Postgres into
+// AppErrors naming the offending field, so that their SQL details never
+// reach the client. Other errors are returned unchanged.
+func translateError(err error) error {
+	pqErr, ok := err.(*pq.Error)
+	if !ok {
+		return err
+	}
+
+	field := constraintFields[pqErr.Constraint]
+	switch pqErr.Code {
+	case pgUniqueViolation:
+		if field == "iin" {
+			return errors.ErrDuplicateIIN
+		}
+		return &errors.AppError{Code: http.StatusConflict, Message: "Record with this " + fieldOrValue(field) + " already exists", Field: field}
+	case pgCheckViolation:
+		return &errors.AppError{Code: http.StatusBadRequest, Message: "Invalid " + fieldOrValue(field), Field: field}
+	case pgNotNullViolation:
+		return &errors.AppError{Code: http.StatusBadRequest, Message: pqErr.Column + " is required", Field: pqErr.Column}
+	}
+	return err
+}
+
+func fieldOrValue(field string) string {
+	if field == "" {
+		return "value"
+	}
+	return field
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	err := translateError(&pq.Error{Code: pgUniqueViolation, Constraint: "people_iin_key"})
+	assert.Equal(t, errors.ErrDuplicateIIN, err)
+
+	err = translateError(&pq.Error{Code: pgCheckViolation, Constraint: "people_phone_check"})
+	assert.Equal(t, &errors.AppError{Code: http.StatusBadRequest, Message: "Invalid phone", Field: "phone"}, err)
+
+	err = translateError(&pq.Error{Code: pgNotNullViolation, Column: "name"})
+	assert.Equal(t, &errors.AppError{Code: http.StatusBadRequest, Message: "name is required", Field: "name"}, err)
+
+	raw := &pq.Error{Code: "40001"}
+	assert.Same(t, raw, translateError(raw))
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// whereBuilder collects SQL conditions joined with AND together with their
+// positional arguments.
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg registers a query argument and returns its $n placeholder.
+func (w *whereBuilder) arg(value interface{}) string {
+	w.args = append(w.args, value)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+func (w *whereBuilder) add(cond string) {
+	w.conds = append(w.conds, cond)
+}
+
+func (w *whereBuilder) String() string {
+	if len(w.conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(w.conds, " AND ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes LIKE wildcards so value is matched literally.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
@@ -1,9 +1,23 @@
 package repository
 
-import "github.com/ddProgerGo/task-kaspi/internal/models"
+import (
+	"context"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+)
 
 type PersonRepositoryInterface interface {
-	SavePerson(person models.Person) error
-	GetPersonByIIN(iin string) (*models.Person, error)
-	GetPeopleByName(namePart string, page int, limit int) ([]models.Person, int, error)
+	SavePerson(ctx context.Context, person models.Person, onConflict string) (*models.SaveResult, error)
+	GetPersonByIIN(ctx context.Context, iin string, includeDeleted bool) (*models.Person, error)
+	ListPeople(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error)
+	ExportPeople(ctx context.Context, query models.PeopleQuery, sink models.PersonSink) error
+	GetPeopleByName(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error)
+	GetPeopleByPhone(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error)
+	UpdatePerson(ctx context.Context, iin string, person models.Person) (*models.Person, error)
+	DeletePerson(ctx context.Context, iin string, deletedBy string) error
+	RestorePerson(ctx context.Context, iin string) (*models.Person, error)
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
+	ImportPeople(ctx context.Context, people []models.Person) (map[string]bool, error)
+	ExistingIINs(ctx context.Context, iins []string) (map[string]bool, error)
 }
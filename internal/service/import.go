@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/sheet"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/go-playground/validator/v10"
+)
+
+// importBatchSize is the number of valid rows written per transaction.
+const importBatchSize = 500
+
+// importColumns maps normalized spreadsheet headers to person fields.
+var importColumns = map[string]string{
+	"name":         "name",
+	"full_name":    "name",
+	"фио":          "name",
+	"last_name":    "last_name",
+	"surname":      "last_name",
+	"фамилия":      "last_name",
+	"first_name":   "first_name",
+	"given_name":   "first_name",
+	"имя":          "first_name",
+	"middle_name":  "middle_name",
+	"patronymic":   "middle_name",
+	"отчество":     "middle_name",
+	"iin":          "iin",
+	"иин":          "iin",
+	"phone":        "phone",
+	"phone_number": "phone",
+	"телефон":      "phone",
+}
+
+// ImportPeople reads people from a spreadsheet whose first row is a header,
+// validates every row like SavePerson does and inserts the valid ones in
+// batches. The report has one entry per non-blank row. With dryRun nothing
+// is written, but duplicates of stored people are still detected.
+//
+// Batches are committed one by one, so when a batch fails after others were
+// written, the report is returned along with the error: it tells which rows
+// were written and marks the others as not processed.
+func (s *PersonService) ImportPeople(ctx context.Context, rows sheet.Reader, dryRun bool) (*models.ImportReport, error) {
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Bulk)
+	defer cancel()
+
+	header, err := rows.Read()
+	if err == io.EOF {
+		return nil, invalidImport("file is empty")
+	}
+	if err != nil {
+		return nil, invalidImport(err.Error())
+	}
+
+	columns, err := mapImportColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{DryRun: dryRun}
+	seen := map[string]int{}
+	var batch []models.Person
+	var pending []int
+	flushed := false
+
+	line := 2
+	for ; ; line++ {
+		record, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			err = invalidImport(fmt.Sprintf("row %d: %s", line, err.Error()))
+			if !flushed {
+				return nil, err
+			}
+			return s.stopImport(report, pending, nil, nil, line, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		person := importedPerson(record, columns)
+		row := models.ImportRow{Row: line, IIN: person.IIN}
+		if err := s.preparePerson(&person); err != nil {
+			row.Status = models.ImportRejected
+			row.Error, row.Field = describeImportError(err)
+		} else if first, ok := seen[person.IIN]; ok {
+			row.Status = models.ImportDuplicate
+			row.Error = fmt.Sprintf("IIN already appears in row %d", first)
+		} else {
+			seen[person.IIN] = line
+			batch = append(batch, person)
+			pending = append(pending, len(report.Rows))
+		}
+		report.Rows = append(report.Rows, row)
+
+		if len(batch) == importBatchSize {
+			if err := s.flushImport(ctx, report, batch, pending); err != nil {
+				return s.stopImport(report, pending, rows, columns, line+1, err)
+			}
+			flushed = true
+			batch, pending = batch[:0], pending[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := s.flushImport(ctx, report, batch, pending); err != nil {
+			return s.stopImport(report, pending, nil, nil, line, err)
+		}
+	}
+
+	summarizeImport(report)
+	s.Logger.Infof("Imported people: %d accepted, %d duplicates, %d rejected (dry run: %t)",
+		report.Accepted, report.Duplicates, report.Rejected, dryRun)
+	return report, nil
+}
+
+// stopImport ends an import that failed with err. The rows of the batch in
+// flight are marked as not processed, and so are the rows not read yet when
+// rows is given, starting at line. The report is returned with err.
+func (s *PersonService) stopImport(report *models.ImportReport, pending []int, rows sheet.Reader, columns map[string]int, line int, err error) (*models.ImportReport, error) {
+	for _, index := range pending {
+		report.Rows[index].Status = models.ImportNotProcessed
+	}
+
+	for ; rows != nil; line++ {
+		record, readErr := rows.Read()
+		if readErr != nil {
+			break
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		person := importedPerson(record, columns)
+		report.Rows = append(report.Rows, models.ImportRow{Row: line, IIN: person.IIN, Status: models.ImportNotProcessed})
+	}
+
+	report.Error = errors.ErrInternalServer.Message
+	if appErr, ok := err.(*errors.AppError); ok {
+		report.Error = appErr.Message
+	}
+	summarizeImport(report)
+	s.Logger.WithError(err).Errorf("Import stopped: %d accepted, %d not processed", report.Accepted, report.NotProcessed)
+	return report, err
+}
+
+func summarizeImport(report *models.ImportReport) {
+	report.Accepted, report.Duplicates, report.Rejected, report.NotProcessed = 0, 0, 0, 0
+	for _, row := range report.Rows {
+		switch row.Status {
+		case models.ImportAccepted:
+			report.Accepted++
+		case models.ImportDuplicate:
+			report.Duplicates++
+		case models.ImportRejected:
+			report.Rejected++
+		case models.ImportNotProcessed:
+			report.NotProcessed++
+		}
+	}
+	report.Total = len(report.Rows)
+}
+
+// flushImport writes a batch, or only checks it against stored people in a
+// dry run, and settles the status of its rows in the report.
+func (s *PersonService) flushImport(ctx context.Context, report *models.ImportReport, batch []models.Person, pending []int) error {
+	var accepted map[string]bool
+	if report.DryRun {
+		iins := make([]string, len(batch))
+		for i, person := range batch {
+			iins[i] = person.IIN
+		}
+		existing, err := s.repo.ExistingIINs(ctx, iins)
+		if err != nil {
+			s.Logger.WithError(err).Error("Failed to check imported IINs")
+			return errors.FromContext(ctx, err)
+		}
+		accepted = make(map[string]bool, len(batch))
+		for _, iin := range iins {
+			accepted[iin] = !existing[iin]
+		}
+	} else {
+		inserted, err := s.repo.ImportPeople(ctx, batch)
+		if err != nil {
+			s.Logger.WithError(err).Error("Failed to import people batch")
+			return errors.FromContext(ctx, err)
+		}
+		accepted = inserted
+
+		// Imported IINs may be cached as not found.
+		var imported []string
+		for iin, ok := range inserted {
+			if ok {
+				imported = append(imported, iin)
+			}
+		}
+		if len(imported) > 0 {
+			s.invalidatePerson(ctx, imported...)
+		}
+	}
+
+	for i, index := range pending {
+		row := &report.Rows[index]
+		if accepted[batch[i].IIN] {
+			row.Status = models.ImportAccepted
+		} else {
+			row.Status = models.ImportDuplicate
+			row.Error = "Person with this IIN already exists"
+		}
+	}
+	return nil
+}
+
+// mapImportColumns resolves the header into a person field per column.
+func mapImportColumns(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, title := range header {
+		normalized := strings.ToLower(strings.Join(strings.FieldsFunc(title, func(r rune) bool {
+			return r == ' ' || r == '-' || r == '_'
+		}), "_"))
+		if field, ok := importColumns[normalized]; ok {
+			if _, dup := columns[field]; dup {
+				return nil, invalidImport(fmt.Sprintf("column %q is mapped to %s twice", title, field))
+			}
+			columns[field] = i
+		}
+	}
+
+	_, hasName := columns["name"]
+	_, hasFirstName := columns["first_name"]
+	_, hasIIN := columns["iin"]
+	_, hasPhone := columns["phone"]
+	if !hasIIN || !hasPhone || (!hasName && !hasFirstName) {
+		return nil, invalidImport("header must contain iin, phone and either name or first_name columns")
+	}
+	return columns, nil
+}
+
+func importedPerson(record []string, columns map[string]int) models.Person {
+	cell := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	// Spreadsheets storing the IIN as a number drop its leading zeros, up to
+	// three for people born in 2000.
+	iin := cell("iin")
+	if len(iin) >= 9 && len(iin) < 12 && strings.Trim(iin, "0123456789") == "" {
+		iin = strings.Repeat("0", 12-len(iin)) + iin
+	}
+
+	return models.Person{
+		Name:       cell("name"),
+		LastName:   cell("last_name"),
+		FirstName:  cell("first_name"),
+		MiddleName: cell("middle_name"),
+		IIN:        iin,
+		Phone:      cell("phone"),
+	}
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// describeImportError turns a validation failure into a report message and
+// the offending field.
+func describeImportError(err error) (string, string) {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		fieldErr := validationErrors[0]
+		field := importFieldNames[fieldErr.Field()]
+		if fieldErr.Param() != "" {
+			return fmt.Sprintf("%s failed %s=%s validation", field, fieldErr.Tag(), fieldErr.Param()), field
+		}
+		return fmt.Sprintf("%s failed %s validation", field, fieldErr.Tag()), field
+	}
+	if appErr, ok := err.(*errors.AppError); ok {
+		// Phone errors name their field; the remaining ones come from ValidateIIN.
+		field := appErr.Field
+		if field == "" {
+			field = "iin"
+		}
+		return appErr.Message, field
+	}
+	return err.Error(), ""
+}
+
+// importFieldNames maps models.Person struct fields to their JSON names.
+var importFieldNames = map[string]string{
+	"Name":       "name",
+	"LastName":   "last_name",
+	"FirstName":  "first_name",
+	"MiddleName": "middle_name",
+	"IIN":        "iin",
+	"Phone":      "phone",
+}
+
+func invalidImport(msg string) error {
+	return &errors.AppError{Code: http.StatusBadRequest, Message: "Invalid import file: " + msg, Field: "file"}
+}
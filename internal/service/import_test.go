@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/repository"
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/ddProgerGo/task-kaspi/internal/sheet"
+	"github.com/ddProgerGo/task-kaspi/internal/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// importRepo stores imported people in memory; other repository methods are
+// not used by the import and panic if called.
+type importRepo struct {
+	repository.PersonRepositoryInterface
+	stored map[string]bool
+	// failAt makes the batch with this 1-based number fail.
+	failAt  int
+	batches int
+}
+
+func (r *importRepo) ImportPeople(ctx context.Context, people []models.Person) (map[string]bool, error) {
+	r.batches++
+	if r.batches == r.failAt {
+		return nil, errors.New("connection reset")
+	}
+	inserted := map[string]bool{}
+	for _, person := range people {
+		if !r.stored[person.IIN] {
+			r.stored[person.IIN] = true
+			inserted[person.IIN] = true
+		}
+	}
+	return inserted, nil
+}
+
+func (r *importRepo) ExistingIINs(ctx context.Context, iins []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	for _, iin := range iins {
+		existing[iin] = r.stored[iin]
+	}
+	return existing, nil
+}
+
+func TestImportPeople(t *testing.T) {
+	gen, err := utils.NewIINGenerator(utils.IINGeneratorOptions{
+		From: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(1999, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}, 1)
+	assert.NoError(t, err)
+	stored, fresh := gen.Next(), gen.Next()
+
+	input := strings.Join([]string{
+		"Фамилия,Имя,ИИН,Телефон",
+		"Нурмеден,Дулат,20304550283,87011234567",
+		"Нурмеден,Дулат,020304550283,87011234567",
+		"Иванов,Иван,123,87011234567",
+		",,,",
+		"Петрова,Алина," + stored + ",+7 705 000 00 00",
+		"Петрова,Алина," + fresh + ",12345",
+	}, "\n")
+
+	for _, dryRun := range []bool{true, false} {
+		repo := &importRepo{stored: map[string]bool{stored: true}}
+		svc := service.NewPersonService(repo, logrus.New(), cache.NewLRU(10))
+
+		rows, err := sheet.NewReader(strings.NewReader(input), sheet.FormatCSV)
+		assert.NoError(t, err)
+
+		report, err := svc.ImportPeople(context.Background(), rows, dryRun)
+		assert.NoError(t, err)
+		assert.Equal(t, dryRun, report.DryRun)
+		assert.Equal(t, []models.ImportRow{
+			{Row: 2, IIN: "020304550283", Status: models.ImportAccepted},
+			{Row: 3, IIN: "020304550283", Status: models.ImportDuplicate, Error: "IIN already appears in row 2"},
+			{Row: 4, IIN: "123", Status: models.ImportRejected, Error: "iin failed len=12 validation", Field: "iin"},
+			{Row: 6, IIN: stored, Status: models.ImportDuplicate, Error: "Person with this IIN already exists"},
+			{Row: 7, IIN: fresh, Status: models.ImportRejected, Error: "Phone must have 10 digits, optionally prefixed with 7, 8 or +7", Field: "phone"},
+		}, report.Rows)
+		assert.Equal(t, 5, report.Total)
+		assert.Equal(t, 1, report.Accepted)
+		assert.Equal(t, 2, report.Duplicates)
+		assert.Equal(t, 2, report.Rejected)
+		assert.Equal(t, !dryRun, repo.stored["020304550283"])
+	}
+}
+
+func TestImportPeopleRequiresColumns(t *testing.T) {
+	svc := service.NewPersonService(&importRepo{}, logrus.New(), cache.Noop{})
+
+	rows, err := sheet.NewReader(strings.NewReader("name,phone\nDulat,87011234567\n"), sheet.FormatCSV)
+	assert.NoError(t, err)
+
+	_, err = svc.ImportPeople(context.Background(), rows, true)
+	assert.Error(t, err)
+}
+
+func TestImportPeopleReportsPartialFailure(t *testing.T) {
+	gen, err := utils.NewIINGenerator(utils.IINGeneratorOptions{
+		From: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(1999, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}, 1)
+	assert.NoError(t, err)
+
+	// 1200 valid rows: the first batch of 500 is written and the second
+	// fails, leaving the rows after it unread.
+	lines := []string{"name,iin,phone"}
+	seen := map[string]bool{}
+	for len(lines) <= 1200 {
+		iin := gen.Next()
+		if seen[iin] {
+			continue
+		}
+		seen[iin] = true
+		lines = append(lines, fmt.Sprintf("Дулат Нурмеден,%s,87011234567", iin))
+	}
+
+	repo := &importRepo{stored: map[string]bool{}, failAt: 2}
+	svc := service.NewPersonService(repo, logrus.New(), cache.Noop{})
+	rows, err := sheet.NewReader(strings.NewReader(strings.Join(lines, "\n")), sheet.FormatCSV)
+	assert.NoError(t, err)
+
+	report, err := svc.ImportPeople(context.Background(), rows, false)
+	assert.Error(t, err)
+	if assert.NotNil(t, report, "the report of a failed import is returned") {
+		assert.Equal(t, 1200, report.Total)
+		assert.Equal(t, 500, report.Accepted)
+		assert.Equal(t, 700, report.NotProcessed)
+		assert.Equal(t, "Internal server error", report.Error)
+		assert.Equal(t, models.ImportAccepted, report.Rows[499].Status)
+		assert.Equal(t, models.ImportNotProcessed, report.Rows[500].Status)
+		assert.Equal(t, models.ImportRow{Row: 1201, IIN: report.Rows[1199].IIN, Status: models.ImportNotProcessed}, report.Rows[1199])
+		assert.Len(t, repo.stored, 500)
+	}
+}
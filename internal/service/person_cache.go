@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+)
+
+// CachePolicy controls how people looked up by IIN are cached.
+type CachePolicy struct {
+	// TTL is how long a found person stays cached.
+	TTL time.Duration
+	// NegativeTTL is how long an IIN that was not found is remembered as
+	// missing. Zero disables negative caching.
+	NegativeTTL time.Duration
+	// Jitter spreads every TTL randomly by up to this fraction of it.
+	Jitter float64
+	// RefreshAhead enables stale-while-revalidate: an entry read less than
+	// RefreshAhead before it expires is still served, and reloaded in the
+	// background. Zero disables it.
+	RefreshAhead time.Duration
+	// SearchTTL is how long a page of a search by name stays cached. Zero
+	// disables caching of search pages.
+	SearchTTL time.Duration
+}
+
+// DefaultCachePolicy remembers missing IINs only briefly, so that a person
+// created by a path that misses the invalidation still shows up soon.
+var DefaultCachePolicy = CachePolicy{
+	TTL:         10 * time.Minute,
+	NegativeTTL: 30 * time.Second,
+	Jitter:      0.1,
+	SearchTTL:   5 * time.Minute,
+}
+
+// cachedPerson is the cache entry of a person looked up by IIN. An entry
+// without a person records that the IIN was not found.
+type cachedPerson struct {
+	Person    *models.Person `json:"person,omitempty"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// lookupPerson returns the person with the given IIN from the cache, loading
+// it on a miss. Concurrent misses for an IIN share a single database query.
+func (s *PersonService) lookupPerson(ctx context.Context, iin string) (*models.Person, error) {
+	key := cache.PersonKey(iin)
+	var entry cachedPerson
+	if err := cache.GetJSON(ctx, s.Cache, key, &entry); err == nil {
+		if s.CachePolicy.RefreshAhead > 0 && time.Until(entry.ExpiresAt) < s.CachePolicy.RefreshAhead {
+			s.flight.Go(key, func() (interface{}, error) {
+				return s.loadPerson(ctx, iin)
+			})
+		}
+		if entry.Person == nil {
+			return nil, errors.ErrNotFound
+		}
+		s.Logger.Info("Data loaded from cache for IIN: ", iin)
+		return entry.Person, nil
+	} else if err != cache.ErrMiss && err != cache.ErrUnavailable {
+		s.Logger.WithError(err).Warn("Failed to read cached person data")
+	}
+
+	person, err := s.flight.Do(ctx, key, func() (interface{}, error) {
+		return s.loadPerson(ctx, iin)
+	})
+	if err != nil {
+		return nil, errors.FromContext(ctx, err)
+	}
+	return person.(*models.Person), nil
+}
+
+// loadPerson reads a person from the database and caches the outcome, not
+// found included. It is shared by every caller waiting on the IIN, so it runs
+// detached from ctx under its own read timeout.
+func (s *PersonService) loadPerson(ctx context.Context, iin string) (*models.Person, error) {
+	ctx, cancel := withTimeout(context.WithoutCancel(ctx), s.Timeouts.Read)
+	defer cancel()
+
+	person, err := s.repo.GetPersonByIIN(ctx, iin, false)
+	if err == errors.ErrNotFound {
+		if s.CachePolicy.NegativeTTL > 0 {
+			s.cachePerson(ctx, iin, nil, s.CachePolicy.NegativeTTL)
+		}
+		return nil, err
+	}
+	if err != nil {
+		s.Logger.WithError(err).Error("Failed to fetch person by IIN")
+		return nil, errors.FromContext(ctx, err)
+	}
+
+	s.cachePerson(ctx, iin, person, s.CachePolicy.TTL)
+	return person, nil
+}
+
+func (s *PersonService) cachePerson(ctx context.Context, iin string, person *models.Person, ttl time.Duration) {
+	ttl = cache.Jitter(ttl, s.CachePolicy.Jitter)
+	entry := cachedPerson{Person: person, ExpiresAt: time.Now().Add(ttl)}
+	if err := cache.SetJSON(ctx, s.Cache, cache.PersonKey(iin), entry, ttl); err != nil && err != cache.ErrUnavailable {
+		s.Logger.WithError(err).Error("Failed to cache person data")
+	}
+}
@@ -0,0 +1,193 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/repository"
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// peopleRepo keeps people by IIN in memory and counts lookups. Lookups wait
+// for release when it is set.
+type peopleRepo struct {
+	repository.PersonRepositoryInterface
+	mu       sync.Mutex
+	people   map[string]models.Person
+	lookups  int
+	searches int
+	release  chan struct{}
+}
+
+func (r *peopleRepo) GetPersonByIIN(ctx context.Context, iin string, includeDeleted bool) (*models.Person, error) {
+	if r.release != nil {
+		<-r.release
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	person, ok := r.people[iin]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	return &person, nil
+}
+
+func (r *peopleRepo) UpdatePerson(ctx context.Context, iin string, person models.Person) (*models.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.people[person.IIN] = person
+	return &person, nil
+}
+
+func (r *peopleRepo) GetPeopleByName(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.searches++
+	var page models.PeoplePage
+	for _, person := range r.people {
+		page.People = append(page.People, person)
+	}
+	return &page, nil
+}
+
+func (r *peopleRepo) Lookups() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lookups
+}
+
+func TestGetPersonByIINCache(t *testing.T) {
+	const iin = "020304550283"
+	repo := &peopleRepo{people: map[string]models.Person{iin: {IIN: iin, Name: "Дулат Нурмеден"}}}
+	store := cache.NewLRU(10)
+	svc := service.NewPersonService(repo, logrus.New(), store)
+	ctx := context.Background()
+
+	person, err := svc.GetPersonByIIN(ctx, iin, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Дулат Нурмеден", person.Name)
+	_, err = store.Get(ctx, cache.PersonKey(iin))
+	assert.NoError(t, err, "the person is cached under its versioned key")
+
+	_, err = svc.GetPersonByIIN(ctx, iin, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, repo.Lookups())
+
+	_, err = svc.UpdatePerson(ctx, iin, models.Person{IIN: iin, Name: "Нурмеден Дулат", Phone: "+77011234567"})
+	assert.NoError(t, err)
+
+	person, err = svc.GetPersonByIIN(ctx, iin, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, repo.Lookups(), "an update invalidates the cached person")
+	assert.Equal(t, "Нурмеден Дулат", person.Name)
+}
+
+func TestGetPersonByIINCoalescesMisses(t *testing.T) {
+	const iin = "020304550283"
+	repo := &peopleRepo{
+		people:  map[string]models.Person{iin: {IIN: iin, Name: "Дулат Нурмеден"}},
+		release: make(chan struct{}),
+	}
+	svc := service.NewPersonService(repo, logrus.New(), cache.NewLRU(10))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			person, err := svc.GetPersonByIIN(context.Background(), iin, false)
+			assert.NoError(t, err)
+			assert.Equal(t, "Дулат Нурмеден", person.Name)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	assert.Equal(t, 1, repo.Lookups())
+}
+
+func TestGetPersonByIINNegativeCache(t *testing.T) {
+	const iin = "020304550283"
+	repo := &peopleRepo{people: map[string]models.Person{}}
+	svc := service.NewPersonService(repo, logrus.New(), cache.NewLRU(10))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.GetPersonByIIN(ctx, iin, false)
+		assert.Equal(t, errors.ErrNotFound, err)
+	}
+	assert.Equal(t, 1, repo.Lookups(), "a missing IIN is remembered")
+
+	// Writing the person drops the negative entry.
+	_, err := svc.UpdatePerson(ctx, iin, models.Person{IIN: iin, Name: "Дулат Нурмеден", Phone: "+77011234567"})
+	assert.NoError(t, err)
+	person, err := svc.GetPersonByIIN(ctx, iin, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Дулат Нурмеден", person.Name)
+}
+
+func TestGetPersonByIINRefreshAhead(t *testing.T) {
+	const iin = "020304550283"
+	repo := &peopleRepo{people: map[string]models.Person{iin: {IIN: iin, Name: "Дулат Нурмеден"}}}
+	svc := service.NewPersonService(repo, logrus.New(), cache.NewLRU(10))
+	svc.CachePolicy = service.CachePolicy{TTL: time.Minute, RefreshAhead: 2 * time.Minute}
+	ctx := context.Background()
+
+	_, err := svc.GetPersonByIIN(ctx, iin, false)
+	assert.NoError(t, err)
+
+	repo.UpdatePerson(ctx, iin, models.Person{IIN: iin, Name: "Нурмеден Дулат"})
+	person, err := svc.GetPersonByIIN(ctx, iin, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Дулат Нурмеден", person.Name, "the cached person is served while it is refreshed")
+
+	assert.Eventually(t, func() bool {
+		person, err := svc.GetPersonByIIN(ctx, iin, false)
+		return err == nil && person.Name == "Нурмеден Дулат"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestGetPeopleByNameCache(t *testing.T) {
+	const iin = "020304550283"
+	repo := &peopleRepo{people: map[string]models.Person{iin: {IIN: iin, Name: "Дулат Нурмеден"}}}
+	svc := service.NewPersonService(repo, logrus.New(), cache.NewLRU(100))
+	ctx := context.Background()
+	query := models.PeopleQuery{Name: "Дулат", Mode: models.SearchModeContains, Page: 1, Limit: 10}
+
+	page, err := svc.GetPeopleByName(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CacheMiss, page.CacheStatus)
+
+	// Spellings the search treats alike share the cached page.
+	query.Name = "dulat"
+	page, err = svc.GetPeopleByName(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CacheHit, page.CacheStatus)
+	assert.Equal(t, "Дулат Нурмеден", page.People[0].Name)
+	assert.Equal(t, 1, repo.searches)
+
+	query.Page = 2
+	page, err = svc.GetPeopleByName(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CacheMiss, page.CacheStatus, "pages are cached separately")
+
+	// Any write drops every cached page.
+	_, err = svc.UpdatePerson(ctx, iin, models.Person{IIN: iin, Name: "Дулат Нурмеденов", Phone: "+77011234567"})
+	assert.NoError(t, err)
+	query.Page = 1
+	page, err = svc.GetPeopleByName(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CacheMiss, page.CacheStatus)
+	assert.Equal(t, "Дулат Нурмеденов", page.People[0].Name)
+	assert.Equal(t, 3, repo.searches)
+}
@@ -3,92 +3,361 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"time"
 
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
 	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/names"
+	"github.com/ddProgerGo/task-kaspi/internal/phone"
 	"github.com/ddProgerGo/task-kaspi/internal/repository"
 	"github.com/ddProgerGo/task-kaspi/internal/utils"
 	"github.com/ddProgerGo/task-kaspi/pkg/errors"
 	"github.com/go-playground/validator/v10"
-	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
 )
 
 type PersonService struct {
-	repo     repository.PersonRepositoryInterface
-	validate *validator.Validate
-	Logger   *logrus.Logger
-	Cache    *redis.Client
+	repo        repository.PersonRepositoryInterface
+	validate    *validator.Validate
+	Logger      *logrus.Logger
+	Cache       cache.Cache
+	CachePolicy CachePolicy
+	Timeouts    Timeouts
+
+	flight cache.Group
 }
 
-func NewPersonService(repo repository.PersonRepositoryInterface, logger *logrus.Logger, cache *redis.Client) *PersonService {
+func NewPersonService(repo repository.PersonRepositoryInterface, logger *logrus.Logger, cache cache.Cache) *PersonService {
 	return &PersonService{
-		repo:     repo,
-		validate: validator.New(),
-		Logger:   logger,
-		Cache:    cache,
+		repo:        repo,
+		validate:    validator.New(),
+		Logger:      logger,
+		Cache:       cache,
+		CachePolicy: DefaultCachePolicy,
+		Timeouts:    DefaultTimeouts,
 	}
 }
 
-func (s *PersonService) SavePerson(person models.Person) error {
-	if err := s.validate.Struct(person); err != nil {
-		return errors.ErrBadRequest
+func (s *PersonService) SavePerson(ctx context.Context, person models.Person, onConflict string) (*models.SaveResult, error) {
+	if err := s.preparePerson(&person); err != nil {
+		s.Logger.WithError(err).Warn("Invalid person data")
+		return nil, requestError(err)
+	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Write)
+	defer cancel()
+
+	result, err := s.repo.SavePerson(ctx, person, onConflict)
+	if err != nil {
+		s.Logger.WithError(err).Error("Failed to save person: ", err)
+		return nil, errors.FromContext(ctx, err)
 	}
 
-	if _, err := utils.ValidateIIN(person.IIN); err != nil {
+	if result.Status != models.SaveIgnored {
+		s.invalidatePerson(ctx, person.IIN)
+	}
+
+	s.Logger.Info("Person saved successfully: ", person.IIN, " (", result.Status, ")")
+	return result, nil
+}
+
+func (s *PersonService) GetPersonByIIN(ctx context.Context, iin string, includeDeleted bool) (*models.Person, error) {
+	if _, err := utils.ValidateIIN(iin); err != nil {
 		s.Logger.WithError(err).Warn("Invalid IIN format")
-		return err
+		return nil, err
 	}
 
-	err := s.repo.SavePerson(person)
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Read)
+	defer cancel()
+
+	if includeDeleted {
+		person, err := s.repo.GetPersonByIIN(ctx, iin, true)
+		if err != nil {
+			s.Logger.WithError(err).Error("Failed to fetch person by IIN")
+			return nil, errors.FromContext(ctx, err)
+		}
+		return person, nil
+	}
+
+	return s.lookupPerson(ctx, iin)
+}
+
+func (s *PersonService) ListPeople(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Search)
+	defer cancel()
+
+	page, err := s.repo.ListPeople(ctx, query)
 	if err != nil {
-		s.Logger.WithError(err).Error("Failed to save person: ", err)
-		return err
+		s.Logger.WithError(err).Error("Failed to list people")
+		return nil, errors.FromContext(ctx, err)
 	}
+	return page, nil
+}
+
+func (s *PersonService) ExportPeople(ctx context.Context, query models.PeopleQuery, sink models.PersonSink) error {
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Bulk)
+	defer cancel()
 
-	s.Logger.Info("Person saved successfully: ", person.IIN)
+	if err := s.repo.ExportPeople(ctx, query, sink); err != nil {
+		s.Logger.WithError(err).Error("Failed to export people")
+		return errors.FromContext(ctx, err)
+	}
 	return nil
 }
 
-func (s *PersonService) GetPersonByIIN(iin string) (*models.Person, error) {
-	ctx := context.Background()
+func (s *PersonService) GetPeopleByName(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Search)
+	defer cancel()
 
+	return s.searchPage(ctx, query, func(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+		page, err := s.repo.GetPeopleByName(ctx, query)
+		if err != nil {
+			s.Logger.WithError(err).Error("Failed to fetch people by name")
+			return nil, errors.FromContext(ctx, err)
+		}
+		return page, nil
+	})
+}
+
+// GetPeopleByPhone matches a complete number exactly and a partial number by prefix.
+func (s *PersonService) GetPeopleByPhone(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	if number, err := phone.Parse(query.Phone); err == nil {
+		query.Phone = number.E164
+		query.PhonePrefix = false
+	} else if _, err := phone.Prefixes(query.Phone); err != nil {
+		s.Logger.WithError(err).Warn("Invalid phone search")
+		return nil, err
+	} else {
+		query.PhonePrefix = true
+	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Search)
+	defer cancel()
+
+	page, err := s.repo.GetPeopleByPhone(ctx, query)
+	if err != nil {
+		s.Logger.WithError(err).Error("Failed to fetch people by phone")
+		return nil, errors.FromContext(ctx, err)
+	}
+	return page, nil
+}
+
+func (s *PersonService) UpdatePerson(ctx context.Context, iin string, person models.Person) (*models.Person, error) {
 	if _, err := utils.ValidateIIN(iin); err != nil {
 		s.Logger.WithError(err).Warn("Invalid IIN format")
 		return nil, err
 	}
 
-	cached, err := s.Cache.Get(ctx, iin).Result()
-	if err == nil {
-		var person models.Person
-		if err := json.Unmarshal([]byte(cached), &person); err == nil {
-			s.Logger.Info("Data loaded from cache for IIN: ", iin)
-			return &person, nil
-		}
+	if err := s.preparePerson(&person); err != nil {
+		s.Logger.WithError(err).Warn("Invalid person data")
+		return nil, requestError(err)
 	}
 
-	person, err := s.repo.GetPersonByIIN(iin)
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Write)
+	defer cancel()
+
+	updated, err := s.repo.UpdatePerson(ctx, iin, person)
 	if err != nil {
-		s.Logger.WithError(err).Error("Failed to fetch person by IIN")
+		s.Logger.WithError(err).Error("Failed to update person: ", err)
+		return nil, errors.FromContext(ctx, err)
+	}
+
+	s.invalidatePerson(ctx, iin, updated.IIN)
+
+	s.Logger.Info("Person updated successfully: ", updated.IIN)
+	return updated, nil
+}
+
+func (s *PersonService) PatchPerson(ctx context.Context, iin string, patch []byte) (*models.Person, error) {
+	if _, err := utils.ValidateIIN(iin); err != nil {
+		s.Logger.WithError(err).Warn("Invalid IIN format")
 		return nil, err
 	}
 
-	data, err := json.Marshal(person)
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Write)
+	defer cancel()
+
+	current, err := s.repo.GetPersonByIIN(ctx, iin, false)
+	if err != nil {
+		s.Logger.WithError(err).Error("Failed to fetch person for patch")
+		return nil, errors.FromContext(ctx, err)
+	}
+
+	original, err := json.Marshal(current)
 	if err != nil {
-		s.Logger.WithError(err).Error("Failed to serialize person data for caching")
+		s.Logger.WithError(err).Error("Failed to serialize person data for patch")
 		return nil, errors.ErrInternalServer
 	}
 
-	if err := s.Cache.Set(ctx, iin, data, 10*time.Minute).Err(); err != nil {
-		s.Logger.WithError(err).Error("Failed to cache person data")
+	merged, err := utils.MergePatch(original, patch)
+	if err != nil {
+		s.Logger.WithError(err).Warn("Invalid merge patch document")
+		return nil, errors.ErrBadRequest
+	}
+
+	var person models.Person
+	if err := json.Unmarshal(merged, &person); err != nil {
+		s.Logger.WithError(err).Warn("Merge patch produced invalid person")
+		return nil, errors.ErrBadRequest
+	}
+	person.ID = current.ID
+
+	// A patch that changes only the full name or only its parts must not
+	// keep the stale counterpart, so drop it and let normalizeName rebuild it.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err == nil {
+		_, name := fields["name"]
+		_, last := fields["last_name"]
+		_, first := fields["first_name"]
+		_, middle := fields["middle_name"]
+		switch {
+		case name && !last && !first && !middle:
+			person.LastName, person.FirstName, person.MiddleName = "", "", ""
+		case !name && (last || first || middle):
+			person.Name = ""
+		}
+	}
+
+	return s.UpdatePerson(ctx, iin, person)
+}
+
+func (s *PersonService) DeletePerson(ctx context.Context, iin string, deletedBy string) error {
+	if _, err := utils.ValidateIIN(iin); err != nil {
+		s.Logger.WithError(err).Warn("Invalid IIN format")
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Write)
+	defer cancel()
+
+	if err := s.repo.DeletePerson(ctx, iin, deletedBy); err != nil {
+		s.Logger.WithError(err).Error("Failed to delete person: ", err)
+		return errors.FromContext(ctx, err)
+	}
+
+	s.invalidatePerson(ctx, iin)
+
+	s.Logger.Info("Person deleted successfully: ", iin)
+	return nil
+}
+
+func (s *PersonService) RestorePerson(ctx context.Context, iin string) (*models.Person, error) {
+	if _, err := utils.ValidateIIN(iin); err != nil {
+		s.Logger.WithError(err).Warn("Invalid IIN format")
+		return nil, err
 	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Write)
+	defer cancel()
+
+	person, err := s.repo.RestorePerson(ctx, iin)
+	if err != nil {
+		s.Logger.WithError(err).Error("Failed to restore person: ", err)
+		return nil, errors.FromContext(ctx, err)
+	}
+
+	s.invalidatePerson(ctx, iin)
+
+	s.Logger.Info("Person restored successfully: ", iin)
 	return person, nil
 }
 
-func (s *PersonService) GetPeopleByName(name string, page int, limit int) ([]models.Person, int, error) {
-	people, total, err := s.repo.GetPeopleByName(name, page, limit)
+// PurgeDeleted hard-deletes people whose soft-deletion is older than the retention period.
+func (s *PersonService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Bulk)
+	defer cancel()
+
+	purged, err := s.repo.PurgeDeletedBefore(ctx, time.Now().Add(-retention))
 	if err != nil {
-		s.Logger.WithError(err).Error("Failed to fetch people by name")
+		s.Logger.WithError(err).Error("Failed to purge deleted people")
+		return 0, errors.FromContext(ctx, err)
+	}
+
+	if purged > 0 {
+		s.invalidateSearches(ctx)
+		s.Logger.Info("Purged soft-deleted people: ", purged)
+	}
+	return purged, nil
+}
+
+// preparePerson normalizes the phone and name, validates the person and fills
+// the attributes derived from the IIN. It returns the first problem found.
+func (s *PersonService) preparePerson(person *models.Person) error {
+	if err := normalizePhone(person); err != nil {
+		return err
+	}
+	normalizeName(person)
+
+	if err := s.validate.Struct(person); err != nil {
+		return err
+	}
+
+	info, err := utils.ValidateIIN(person.IIN)
+	if err != nil {
+		return err
+	}
+	applyIINInfo(person, info)
+	return nil
+}
+
+// requestError hides validator details behind the generic bad request error.
+func requestError(err error) error {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return errors.ErrBadRequest
+	}
+	return err
+}
+
+// applyIINInfo fills the attributes derived from the IIN, overriding client-supplied values.
+func applyIINInfo(person *models.Person, info *utils.IINInfo) {
+	person.BirthDate = info.BirthDate
+	person.Sex = info.Sex
+}
+
+// normalizeName keeps the full name and its parts consistent: parts are split
+// out of the full name when none are given, and the full name is composed
+// from the parts when it is missing.
+func normalizeName(person *models.Person) {
+	person.Name = strings.Join(strings.Fields(person.Name), " ")
+	if person.LastName == "" && person.FirstName == "" && person.MiddleName == "" {
+		parts := names.Split(person.Name)
+		person.LastName, person.FirstName, person.MiddleName = parts.LastName, parts.FirstName, parts.MiddleName
+		return
+	}
+
+	if person.Name == "" {
+		person.Name = names.Join(names.Parts{LastName: person.LastName, FirstName: person.FirstName, MiddleName: person.MiddleName})
+	}
+}
+
+// normalizePhone rewrites the phone to E.164 and fills the operator details.
+func normalizePhone(person *models.Person) error {
+	number, err := phone.Parse(person.Phone)
+	if err != nil {
+		return err
+	}
+
+	person.Phone = number.E164
+	person.PhoneOperator = number.Operator
+	person.PhoneType = number.Type
+	return nil
+}
+
+// invalidatePerson drops cached people, and every cached search page, after
+// a write. It runs even when ctx was canceled meanwhile, since the write has
+// already happened. While the cache is unavailable the breaker keeps the keys
+// to delete on recovery.
+func (s *PersonService) invalidatePerson(ctx context.Context, iins ...string) {
+	keys := append(cache.PersonKeys(iins...), cache.SearchGenerationKey)
+	if err := s.Cache.Delete(context.WithoutCancel(ctx), keys...); err != nil && err != cache.ErrUnavailable {
+		s.Logger.WithError(err).Error("Failed to invalidate cached person data")
+	}
+}
+
+// invalidateSearches drops every cached search page.
+func (s *PersonService) invalidateSearches(ctx context.Context) {
+	if err := s.Cache.Delete(context.WithoutCancel(ctx), cache.SearchGenerationKey); err != nil && err != cache.ErrUnavailable {
+		s.Logger.WithError(err).Error("Failed to invalidate cached search pages")
 	}
-	return people, total, err
 }
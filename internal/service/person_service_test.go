@@ -0,0 +1,32 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePersonRejectsInvalidIIN(t *testing.T) {
+	// The repository is never reached: any call would panic on the nil
+	// embedded interface.
+	svc := service.NewPersonService(&peopleRepo{}, logrus.New(), cache.Noop{})
+	ctx := context.Background()
+
+	_, err := svc.UpdatePerson(ctx, "123", models.Person{IIN: "020304550283", Name: "Дулат Нурмеден", Phone: "+77011234567"})
+	var appErr *errors.AppError
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+	}
+
+	_, err = svc.PatchPerson(ctx, "020304550284", []byte(`{"name":"Дулат"}`))
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+	}
+}
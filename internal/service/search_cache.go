@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/cache"
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/translit"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+)
+
+// cachedPage is the cache entry of a search page.
+type cachedPage struct {
+	People   []models.Person `json:"people"`
+	Total    *int            `json:"total,omitempty"`
+	Next     *models.Cursor  `json:"next,omitempty"`
+	Prev     *models.Cursor  `json:"prev,omitempty"`
+	CachedAt time.Time       `json:"cached_at"`
+}
+
+type pageLoader func(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error)
+
+// searchPage returns a search page from the cache, loading it with load on a
+// miss. Pages are cached under the current search generation, which every
+// write drops (see invalidatePerson), so a page never outlives a change that
+// could affect it. Concurrent misses for a page share a single load.
+func (s *PersonService) searchPage(ctx context.Context, query models.PeopleQuery, load pageLoader) (*models.PeoplePage, error) {
+	if s.CachePolicy.SearchTTL <= 0 {
+		return load(ctx, query)
+	}
+
+	generation, err := s.searchGeneration(ctx)
+	if err != nil {
+		if err != cache.ErrUnavailable {
+			s.Logger.WithError(err).Warn("Failed to read search cache generation")
+		}
+		page, err := load(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		page.CacheStatus = models.CacheMiss
+		return page, nil
+	}
+
+	key, err := searchPageKey(generation, query)
+	if err != nil {
+		s.Logger.WithError(err).Error("Failed to build search cache key")
+		return load(ctx, query)
+	}
+
+	var entry cachedPage
+	if err := cache.GetJSON(ctx, s.Cache, key, &entry); err == nil {
+		return &models.PeoplePage{
+			People:      entry.People,
+			Total:       entry.Total,
+			Next:        entry.Next,
+			Prev:        entry.Prev,
+			CacheStatus: models.CacheHit,
+			CachedAt:    entry.CachedAt,
+		}, nil
+	} else if err != cache.ErrMiss && err != cache.ErrUnavailable {
+		s.Logger.WithError(err).Warn("Failed to read cached search page")
+	}
+
+	loaded, err := s.flight.Do(ctx, key, func() (interface{}, error) {
+		// Shared by every caller waiting on the page, so detached from ctx.
+		ctx, cancel := withTimeout(context.WithoutCancel(ctx), s.Timeouts.Search)
+		defer cancel()
+
+		page, err := load(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		entry := cachedPage{People: page.People, Total: page.Total, Next: page.Next, Prev: page.Prev, CachedAt: time.Now()}
+		if err := cache.SetJSON(ctx, s.Cache, key, entry, cache.Jitter(s.CachePolicy.SearchTTL, s.CachePolicy.Jitter)); err != nil && err != cache.ErrUnavailable {
+			s.Logger.WithError(err).Error("Failed to cache search page")
+		}
+		return page, nil
+	})
+	if err != nil {
+		return nil, errors.FromContext(ctx, err)
+	}
+
+	page := *loaded.(*models.PeoplePage)
+	page.CacheStatus = models.CacheMiss
+	return &page, nil
+}
+
+// searchGeneration returns the current search generation, starting a new one
+// when it was dropped.
+func (s *PersonService) searchGeneration(ctx context.Context) (string, error) {
+	generation, err := s.Cache.Get(ctx, cache.SearchGenerationKey)
+	if err == nil {
+		return string(generation), nil
+	}
+	if err != cache.ErrMiss {
+		return "", err
+	}
+
+	next := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := s.Cache.Set(ctx, cache.SearchGenerationKey, []byte(next), 0); err != nil {
+		return "", err
+	}
+	return next, nil
+}
+
+// searchPageKey identifies a search by its whole query, with the name reduced
+// to its search key so that spellings the search treats alike share a page.
+func searchPageKey(generation string, query models.PeopleQuery) (string, error) {
+	query.Name = translit.SearchKey(query.Name)
+	data, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return cache.SearchPageKey(generation, hex.EncodeToString(hash[:])), nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts bounds how long each kind of operation may run, on top of any
+// deadline the caller's context already has. Zero leaves an operation
+// bounded only by the caller.
+type Timeouts struct {
+	// Read applies to lookups of a single person.
+	Read time.Duration
+	// Search applies to listings and searches.
+	Search time.Duration
+	// Write applies to saves, updates, deletions and restores.
+	Write time.Duration
+	// Bulk applies to imports, exports and purges.
+	Bulk time.Duration
+}
+
+// DefaultTimeouts leaves bulk operations unbounded, as they grow with the
+// data and are canceled with their request or job instead.
+var DefaultTimeouts = Timeouts{
+	Read:   2 * time.Second,
+	Search: 5 * time.Second,
+	Write:  5 * time.Second,
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
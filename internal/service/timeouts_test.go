@@ -0,0 +1,38 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/models"
+	"github.com/ddProgerGo/task-kaspi/internal/repository"
+	"github.com/ddProgerGo/task-kaspi/internal/service"
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingRepo lists people only once its context ends, like a query that
+// is still running when it is canceled.
+type blockingRepo struct {
+	repository.PersonRepositoryInterface
+}
+
+func (r *blockingRepo) ListPeople(ctx context.Context, query models.PeopleQuery) (*models.PeoplePage, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestOperationDeadlines(t *testing.T) {
+	svc := service.NewPersonService(&blockingRepo{}, logrus.New(), nil)
+	svc.Timeouts.Search = 10 * time.Millisecond
+
+	_, err := svc.ListPeople(context.Background(), models.PeopleQuery{})
+	assert.Equal(t, errors.ErrTimeout, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = svc.ListPeople(ctx, models.PeopleQuery{})
+	assert.Equal(t, errors.ErrRequestCanceled, err)
+}
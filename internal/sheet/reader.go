@@ -0,0 +1,112 @@
+package sheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Reader yields the rows of a spreadsheet one at a time and returns io.EOF
+// after the last one.
+type Reader interface {
+	Read() ([]string, error)
+}
+
+// DetectFormat infers the spreadsheet format from a file name or a content
+// type, returning an empty string when neither is recognised.
+func DetectFormat(filename, contentType string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".xlsx":
+		return FormatXLSX
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return FormatCSV
+	case strings.HasPrefix(contentType, xlsxContentType):
+		return FormatXLSX
+	}
+	return ""
+}
+
+// NewReader returns a reader for r in the given format. CSV input may start
+// with a UTF-8 byte order mark and use either commas or semicolons, as Excel
+// writes depending on the locale. XLSX is read from its first sheet, with
+// numeric cells returned unformatted.
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatXLSX:
+		return newXLSXReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported spreadsheet format %q", format)
+	}
+}
+
+func newCSVReader(r io.Reader) (Reader, error) {
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		buffered.Discard(3)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Sniff the delimiter from the header line without consuming it.
+	header, _ := buffered.Peek(buffered.Size())
+	if line, _, _ := bytes.Cut(header, []byte("\n")); bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		reader.Comma = ';'
+	}
+	return reader, nil
+}
+
+type xlsxReader struct {
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func newXLSXReader(r io.Reader) (Reader, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+
+	rows, err := file.Rows(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+	return &xlsxReader{file: file, rows: rows}, nil
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	if !x.rows.Next() {
+		err := x.rows.Error()
+		x.rows.Close()
+		x.file.Close()
+		if err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	// Raw values keep long numbers such as IINs and phones out of scientific
+	// notation.
+	return x.rows.Columns(excelize.Options{RawCellValue: true})
+}
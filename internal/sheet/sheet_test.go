@@ -0,0 +1,57 @@
+package sheet_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/sheet"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, r sheet.Reader) [][]string {
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		if !assert.NoError(t, err) {
+			return records
+		}
+		records = append(records, record)
+	}
+}
+
+func TestCSVReaderHandlesBOMAndSemicolons(t *testing.T) {
+	input := "\xef\xbb\xbfФИО;ИИН;Телефон\nДулат Нурмеден;020304550283;\"+7 701 123-45-67\"\n"
+	r, err := sheet.NewReader(strings.NewReader(input), sheet.FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"ФИО", "ИИН", "Телефон"},
+		{"Дулат Нурмеден", "020304550283", "+7 701 123-45-67"},
+	}, readAll(t, r))
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	records := [][]string{{"name", "iin", "phone"}, {"Dulat Nurmeden", "020304550283", "+77011234567"}}
+
+	var buf bytes.Buffer
+	w, err := sheet.NewWriter(&buf, sheet.FormatXLSX)
+	assert.NoError(t, err)
+	for _, record := range records {
+		assert.NoError(t, w.Write(record))
+	}
+	assert.NoError(t, w.Close())
+
+	r, err := sheet.NewReader(&buf, sheet.FormatXLSX)
+	assert.NoError(t, err)
+	assert.Equal(t, records, readAll(t, r))
+}
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, sheet.FormatCSV, sheet.DetectFormat("people.CSV", ""))
+	assert.Equal(t, sheet.FormatXLSX, sheet.DetectFormat("", sheet.ContentType(sheet.FormatXLSX)))
+	assert.Equal(t, "", sheet.DetectFormat("people.xls", "application/octet-stream"))
+}
@@ -0,0 +1,86 @@
+package sheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ContentType returns the MIME type of a spreadsheet format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return xlsxContentType
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes spreadsheet rows. Close must be called to flush the output;
+// it does not close the underlying writer.
+type Writer interface {
+	Write(record []string) error
+	Close() error
+}
+
+// NewWriter returns a writer producing the given format on w. XLSX output is
+// written to w only on Close, as the format is a zip archive.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported spreadsheet format %q", format)
+	}
+}
+
+type csvWriter struct {
+	*csv.Writer
+}
+
+func (c *csvWriter) Close() error {
+	c.Flush()
+	return c.Error()
+}
+
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer) (Writer, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{out: w, file: file, stream: stream}, nil
+}
+
+func (x *xlsxWriter) Write(record []string) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(record))
+	for i, value := range record {
+		values[i] = value
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.out)
+	return err
+}
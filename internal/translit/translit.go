@@ -0,0 +1,79 @@
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// runeKeys folds Cyrillic (including Kazakh-specific letters) and accented Latin
+// letters onto a plain Latin skeleton.
+var runeKeys = map[rune]string{
+	'а': "a", 'ә': "a", 'б': "b", 'в': "v", 'г': "g", 'ғ': "g", 'д': "d",
+	'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'і': "i",
+	'к': "k", 'қ': "k", 'л': "l", 'м': "m", 'н': "n", 'ң': "n", 'о': "o",
+	'ө': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ұ': "u",
+	'ү': "u", 'ф': "f", 'х': "h", 'һ': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh",
+	'щ': "sh", 'ъ': "", 'ы': "i", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+
+	'ä': "a", 'á': "a", 'à': "a", 'â': "a", 'ğ': "g", 'ı': "i", 'í': "i",
+	'ì': "i", 'ï': "i", 'î': "i", 'ñ': "n", 'ń': "n", 'ö': "o", 'ó': "o",
+	'ô': "o", 'ū': "u", 'ü': "u", 'ú': "u", 'ù': "u", 'û': "u", 'ş': "sh",
+	'ś': "sh", 'š': "sh", 'ç': "ch", 'č': "ch", 'ž': "zh", 'ź': "zh",
+	'ż': "zh", 'é': "e", 'è': "e", 'ê': "e", 'ë': "e", 'ý': "i", 'ÿ': "i",
+}
+
+// latinKeys reconciles the official romanizations (BGN/PCGN, ISO 9, Kazakh
+// Latin alphabets). Identity entries keep digraphs from being split by the
+// single-letter rules that follow them. "j" is read as ж, as in the Kazakh
+// Latin alphabet, rather than as the ISO 9 й.
+var latinKeys = strings.NewReplacer(
+	"shch", "sh", "sch", "sh",
+	"ch", "ch", "sh", "sh", "zh", "zh", "ts", "ts",
+	"kh", "h", "gh", "g",
+	"c", "ts", "q", "k", "j", "zh", "x", "h", "w", "u", "y", "i",
+)
+
+// SearchKey returns a script-independent key for a person's name, so that
+// "Дулат Нурмеден", "Dulat Nurmeden" and "DULAT NURMEDEN" share one key.
+func SearchKey(name string) string {
+	var folded strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if key, ok := runeKeys[r]; ok {
+			folded.WriteString(key)
+		} else if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			folded.WriteRune(r)
+		} else if unicode.IsSpace(r) || r == '-' {
+			folded.WriteRune(' ')
+		}
+	}
+
+	words := strings.Fields(latinKeys.Replace(folded.String()))
+	for i, word := range words {
+		words[i] = squeeze(normalizeWordStart(word))
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeWordStart maps the BGN/PCGN word-initial "ye" (already folded to
+// "ie") back to "e", as in "Yerlan" for "Ерлан".
+func normalizeWordStart(word string) string {
+	if strings.HasPrefix(word, "ie") {
+		return word[1:]
+	}
+	return word
+}
+
+// squeeze collapses runs of the same letter, which romanizations double
+// inconsistently ("Yuliya"/"Yulia", "Khairulla"/"Hairula").
+func squeeze(word string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range word {
+		if i > 0 && r == prev {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package translit_test
+
+import (
+	"testing"
+
+	"github.com/ddProgerGo/task-kaspi/internal/translit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchKeyMatchesAcrossScripts(t *testing.T) {
+	groups := [][]string{
+		{"Дулат Нурмеден", "Dulat Nurmeden", "DULAT  NURMEDEN", "Дулат Нурмедён"},
+		{"Жанар", "Zhanar", "Janar", "Žanar"},
+		{"Қайрат", "Kairat", "Qairat", "Kayrat"},
+		{"Әлия", "Aliya", "Äliia", "Aliia"},
+		{"Нұрсұлтан", "Nursultan", "Nūrsūltan"},
+		{"Ерлан", "Yerlan", "Erlan"},
+		{"Ғалымжан", "Galymzhan", "Ğalymjan", "Ghalymzhan"},
+		{"Хайрулла", "Khairulla", "Hairula"},
+		{"Шыңғыс", "Shyngys", "Şyñğys"},
+		{"Юлия", "Yuliya", "Yulia", "Iuliia"},
+		{"Цой", "Tsoi", "Tsoy", "Coi"},
+	}
+
+	for _, group := range groups {
+		expected := translit.SearchKey(group[0])
+		for _, name := range group[1:] {
+			assert.Equal(t, expected, translit.SearchKey(name), "%s vs %s", group[0], name)
+		}
+	}
+}
+
+func TestSearchKeyNormalizesSeparators(t *testing.T) {
+	assert.Equal(t, "muhamed ali", translit.SearchKey("Мухамед-Али"))
+	assert.Equal(t, "", translit.SearchKey(" ' "))
+}
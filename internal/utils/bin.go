@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/pkg/errors"
+)
+
+const typeRegistrationDate = "01.2006"
+
+const (
+	IDTypeIIN = "iin"
+	IDTypeBIN = "bin"
+)
+
+var binEntityTypes = map[byte]string{
+	'4': "resident_legal_entity",
+	'5': "non_resident_legal_entity",
+	'6': "joint_individual_entrepreneur",
+}
+
+var binDivisions = map[byte]string{
+	'0': "head_office",
+	'1': "branch",
+	'2': "representative_office",
+	'3': "peasant_farm",
+}
+
+type BINInfo struct {
+	Correct          bool   `json:"correct"`
+	RegistrationDate string `json:"registration_date"`
+	EntityType       string `json:"entity_type"`
+	Division         string `json:"division"`
+}
+
+type IDInfo struct {
+	Type string   `json:"type"`
+	IIN  *IINInfo `json:"iin,omitempty"`
+	BIN  *BINInfo `json:"bin,omitempty"`
+}
+
+func ValidateBIN(bin string) (*BINInfo, error) {
+	if len(bin) != 12 {
+		return nil, errors.ErrInvalidBINLength
+	}
+
+	for _, r := range bin {
+		if r < '0' || r > '9' {
+			return nil, errors.ErrInvalidBINFormat
+		}
+	}
+
+	year, _ := strconv.Atoi(bin[0:2])
+	month, _ := strconv.Atoi(bin[2:4])
+	if month < 1 || month > 12 {
+		return nil, errors.ErrInvalidBINDate
+	}
+
+	fullYear := 2000 + year
+	if fullYear > time.Now().Year() {
+		fullYear -= 100
+	}
+
+	entityType, ok := binEntityTypes[bin[4]]
+	if !ok {
+		return nil, errors.ErrInvalidEntityType
+	}
+
+	division, ok := binDivisions[bin[5]]
+	if !ok {
+		return nil, errors.ErrInvalidBINDivision
+	}
+
+	if !isValidChecksum(bin) {
+		return nil, errors.ErrInvalidBINChecksum
+	}
+
+	return &BINInfo{
+		Correct:          true,
+		RegistrationDate: time.Date(fullYear, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format(typeRegistrationDate),
+		EntityType:       entityType,
+		Division:         division,
+	}, nil
+}
+
+// DetectIDType tells IINs and BINs apart by the 5th digit: it is the tens of the
+// birth day (0-3) in an IIN and the entity type (4-6) in a BIN.
+func DetectIDType(number string) (string, error) {
+	if len(number) != 12 {
+		return "", errors.ErrInvalidIDLength
+	}
+
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return "", errors.ErrInvalidIDFormat
+		}
+	}
+
+	switch {
+	case number[4] <= '3':
+		return IDTypeIIN, nil
+	case number[4] <= '6':
+		return IDTypeBIN, nil
+	default:
+		return "", errors.ErrUnknownIDType
+	}
+}
+
+// ValidateID detects whether number is an IIN or a BIN and validates it accordingly.
+func ValidateID(number string) (*IDInfo, error) {
+	idType, err := DetectIDType(number)
+	if err != nil {
+		return nil, err
+	}
+
+	if idType == IDTypeIIN {
+		info, err := ValidateIIN(number)
+		if err != nil {
+			return nil, err
+		}
+		return &IDInfo{Type: idType, IIN: info}, nil
+	}
+
+	info, err := ValidateBIN(number)
+	if err != nil {
+		return nil, err
+	}
+	return &IDInfo{Type: idType, BIN: info}, nil
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
+type IINGeneratorOptions struct {
+	// From and To bound the generated birth dates, inclusive.
+	From time.Time
+	To   time.Time
+	// Sex is SexMale, SexFemale or empty for either.
+	Sex string
+	// Century is the first year of the century (1800, 1900 or 2000) or 0 for any;
+	// it narrows From and To to that century.
+	Century int
+}
+
+// IINGenerator produces valid IINs. Generators created with the same seed and
+// options produce the same sequence.
+type IINGenerator struct {
+	rnd  *rand.Rand
+	from time.Time
+	days int
+	sex  string
+}
+
+func NewIINGenerator(opts IINGeneratorOptions, seed int64) (*IINGenerator, error) {
+	from := truncateDate(opts.From)
+	to := truncateDate(opts.To)
+
+	if opts.Century != 0 {
+		if opts.Century != 1800 && opts.Century != 1900 && opts.Century != 2000 {
+			return nil, fmt.Errorf("unsupported century %d", opts.Century)
+		}
+		centuryStart := time.Date(opts.Century, time.January, 1, 0, 0, 0, 0, time.UTC)
+		centuryEnd := time.Date(opts.Century+99, time.December, 31, 0, 0, 0, 0, time.UTC)
+		if from.Before(centuryStart) {
+			from = centuryStart
+		}
+		if to.After(centuryEnd) {
+			to = centuryEnd
+		}
+	}
+
+	if from.Year() < 1800 || to.Year() > 2099 {
+		return nil, fmt.Errorf("birth dates must be between 1800 and 2099")
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("empty birth date range %s - %s", from.Format(typeDate), to.Format(typeDate))
+	}
+
+	if opts.Sex != "" && opts.Sex != SexMale && opts.Sex != SexFemale {
+		return nil, fmt.Errorf("unsupported sex %q", opts.Sex)
+	}
+
+	return &IINGenerator{
+		rnd:  rand.New(rand.NewSource(seed)),
+		from: from,
+		// Counted from Unix seconds: a time.Duration saturates at about 292
+		// years, less than the 1800-2099 range.
+		days: int((to.Unix() - from.Unix()) / 86400),
+		sex:  opts.Sex,
+	}, nil
+}
+
+// Next returns the next valid IIN.
+func (g *IINGenerator) Next() string {
+	for {
+		birthDate := g.from.AddDate(0, 0, g.rnd.Intn(g.days+1))
+
+		sex := g.sex
+		if sex == "" {
+			sex = SexMale
+			if g.rnd.Intn(2) == 1 {
+				sex = SexFemale
+			}
+		}
+
+		prefix := birthDate.Format("060102") +
+			strconv.Itoa(centuryGenderDigit(birthDate.Year(), sex)) +
+			fmt.Sprintf("%04d", g.rnd.Intn(10000))
+
+		controlDigit, _ := computeCheckDigit(prefix + "0")
+		if controlDigit == 10 {
+			continue
+		}
+
+		return prefix + strconv.Itoa(controlDigit)
+	}
+}
+
+// centuryGenderDigit returns the 7th IIN digit: 1-2 for the 1800s, 3-4 for the
+// 1900s and 5-6 for the 2000s, odd for men and even for women.
+func centuryGenderDigit(year int, sex string) int {
+	digit := (year/100-18)*2 + 1
+	if sex == SexFemale {
+		digit++
+	}
+	return digit
+}
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,80 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ddProgerGo/task-kaspi/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIINGeneratorProducesValidIINs(t *testing.T) {
+	opts := utils.IINGeneratorOptions{
+		From: time.Date(1899, time.June, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2005, time.June, 1, 0, 0, 0, 0, time.UTC),
+		Sex:  utils.SexFemale,
+	}
+
+	gen, err := utils.NewIINGenerator(opts, 1)
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		iin := gen.Next()
+		info, err := utils.ValidateIIN(iin)
+		require.NoError(t, err, iin)
+		assert.Equal(t, utils.SexFemale, info.Sex)
+
+		birthDate, _ := time.Parse("02.01.2006", info.DateOfBirth)
+		assert.False(t, birthDate.Before(opts.From) || birthDate.After(opts.To), iin)
+	}
+}
+
+func TestIINGeneratorIsReproducible(t *testing.T) {
+	opts := utils.IINGeneratorOptions{
+		From:    time.Date(1950, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC),
+		Century: 1900,
+	}
+
+	first, err := utils.NewIINGenerator(opts, 42)
+	require.NoError(t, err)
+	second, err := utils.NewIINGenerator(opts, 42)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		iin := first.Next()
+		assert.Equal(t, iin, second.Next())
+		assert.Contains(t, "34", string(iin[6]), "century digit must be in the 1900s")
+	}
+}
+
+func TestIINGeneratorRejectsEmptyRange(t *testing.T) {
+	_, err := utils.NewIINGenerator(utils.IINGeneratorOptions{
+		From:    time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2010, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Century: 1900,
+	}, 1)
+	assert.Error(t, err)
+}
+
+func TestIINGeneratorCoversWideRange(t *testing.T) {
+	gen, err := utils.NewIINGenerator(utils.IINGeneratorOptions{
+		From: time.Date(1800, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2099, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}, 1)
+	require.NoError(t, err)
+
+	// The range is wider than a time.Duration; its last years must still be
+	// generated.
+	latest := 0
+	for i := 0; i < 2000; i++ {
+		info, err := utils.ValidateIIN(gen.Next())
+		require.NoError(t, err)
+		birthDate, _ := time.Parse("02.01.2006", info.DateOfBirth)
+		if birthDate.Year() > latest {
+			latest = birthDate.Year()
+		}
+	}
+	assert.GreaterOrEqual(t, latest, 2093)
+}
@@ -7,15 +7,34 @@ import (
 	"github.com/ddProgerGo/task-kaspi/pkg/errors"
 )
 
-const typeDate = "02.01.2006"
+const (
+	typeDate    = "02.01.2006"
+	typeISODate = "2006-01-02"
+	adultAge    = 18
+)
 
 type IINInfo struct {
-	Correct     bool   `json:"correct"`
-	Sex         string `json:"sex"`
-	DateOfBirth string `json:"date_of_birth"`
+	Correct      bool   `json:"correct"`
+	Sex          string `json:"sex"`
+	DateOfBirth  string `json:"date_of_birth"`
+	BirthDate    string `json:"birth_date"`
+	Age          int    `json:"age"`
+	IsAdult      bool   `json:"is_adult"`
+	AsOf         string `json:"as_of"`
+	BornInFuture bool   `json:"born_in_future"`
+	Century      int    `json:"century"`
+	CenturyDigit int    `json:"century_digit"`
+	SerialNumber string `json:"serial_number"`
+	ControlDigit int    `json:"control_digit"`
+	ChecksumPass int    `json:"checksum_pass"`
 }
 
 func ValidateIIN(iin string) (*IINInfo, error) {
+	return ValidateIINAt(iin, time.Now())
+}
+
+// ValidateIINAt validates the IIN and computes age-related fields as of the given date.
+func ValidateIINAt(iin string, asOf time.Time) (*IINInfo, error) {
 	if len(iin) != 12 {
 		return nil, errors.ErrInvalidIINLength
 	}
@@ -31,19 +50,20 @@ func ValidateIIN(iin string) (*IINInfo, error) {
 	day, _ := strconv.Atoi(iin[4:6])
 
 	centuryGender, _ := strconv.Atoi(string(iin[6]))
-	var fullYear int
+	var century int
 	var gender string
 
 	switch centuryGender {
 	case 1, 2:
-		fullYear = 1800 + year
+		century = 1800
 	case 3, 4:
-		fullYear = 1900 + year
+		century = 1900
 	case 5, 6:
-		fullYear = 2000 + year
+		century = 2000
 	default:
 		return nil, errors.ErrInvalidCenturyCode
 	}
+	fullYear := century + year
 
 	if centuryGender%2 == 1 {
 		gender = "male"
@@ -59,34 +79,87 @@ func ValidateIIN(iin string) (*IINInfo, error) {
 	if !isValidChecksum(iin) {
 		return nil, errors.ErrInvalidIINChecksum
 	}
+	controlDigit, checksumPass := computeCheckDigit(iin)
+
+	asOfDate := truncateDate(asOf)
+	age := ageAt(dateOfBirth, asOfDate)
 
 	return &IINInfo{
-		true,
-		gender,
-		dateOfBirth.Format(typeDate),
+		Correct:      true,
+		Sex:          gender,
+		DateOfBirth:  dateOfBirth.Format(typeDate),
+		BirthDate:    dateOfBirth.Format(typeISODate),
+		Age:          age,
+		IsAdult:      age >= adultAge,
+		AsOf:         asOfDate.Format(typeISODate),
+		BornInFuture: dateOfBirth.After(asOfDate),
+		Century:      century,
+		CenturyDigit: centuryGender,
+		SerialNumber: iin[7:11],
+		ControlDigit: controlDigit,
+		ChecksumPass: checksumPass,
 	}, nil
 }
 
-func isValidChecksum(iin string) bool {
+// ageAt returns the number of full years between birth and date, never negative.
+func ageAt(birth, date time.Time) int {
+	age := date.Year() - birth.Year()
+	if date.Month() < birth.Month() || (date.Month() == birth.Month() && date.Day() < birth.Day()) {
+		age--
+	}
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+func isValidChecksum(number string) bool {
+	controlDigit, _ := computeCheckDigit(number)
+
+	expectedDigit, _ := strconv.Atoi(string(number[11]))
+	return controlDigit < 10 && controlDigit == expectedDigit
+}
+
+// computeCheckDigit returns the control digit for the first 11 digits of number
+// and the weight pass (1 or 2) that produced it. A result of 10 means the number
+// cannot have a valid control digit.
+func computeCheckDigit(number string) (int, int) {
 	weights1 := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
 	weights2 := []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 1, 2}
 
 	sum := 0
 	for i := 0; i < 11; i++ {
-		digit, _ := strconv.Atoi(string(iin[i]))
+		digit, _ := strconv.Atoi(string(number[i]))
 		sum += digit * weights1[i]
 	}
 
 	controlDigit := sum % 11
-	if controlDigit == 10 {
-		sum = 0
-		for i := 0; i < 11; i++ {
-			digit, _ := strconv.Atoi(string(iin[i]))
-			sum += digit * weights2[i]
-		}
-		controlDigit = sum % 11
+	if controlDigit != 10 {
+		return controlDigit, 1
 	}
 
-	expectedDigit, _ := strconv.Atoi(string(iin[11]))
-	return controlDigit < 10 && controlDigit == expectedDigit
+	sum = 0
+	for i := 0; i < 11; i++ {
+		digit, _ := strconv.Atoi(string(number[i]))
+		sum += digit * weights2[i]
+	}
+	return sum % 11, 2
+}
+
+// IINErrorReason returns a machine-readable reason for an IIN validation error.
+func IINErrorReason(err error) string {
+	switch err {
+	case errors.ErrInvalidIINLength:
+		return "length"
+	case errors.ErrInvalidIINFormat:
+		return "format"
+	case errors.ErrInvalidCenturyCode:
+		return "century_code"
+	case errors.ErrInvalidDateOfBirth:
+		return "date_of_birth"
+	case errors.ErrInvalidIINChecksum:
+		return "checksum"
+	default:
+		return "unknown"
+	}
 }
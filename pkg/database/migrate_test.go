@@ -0,0 +1,31 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrationsAreOrderedAndReversible(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+		assert.Len(t, migration.Checksum, 64)
+	}
+}
+
+func TestLoadMigrationsRequiresDownFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_init.up.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	_, err := loadMigrations(fsys, "migrations")
+	assert.Error(t, err)
+}
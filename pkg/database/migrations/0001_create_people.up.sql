@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS people (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    iin CHAR(12) UNIQUE NOT NULL CHECK (iin ~ '^[0-9]{12}$'),
+    phone VARCHAR(20) NOT NULL CHECK (phone ~ '^[0-9+\-() ]+$')
+);
+
+CREATE INDEX IF NOT EXISTS idx_people_name ON people (name);
+CREATE INDEX IF NOT EXISTS idx_people_iin ON people (iin);
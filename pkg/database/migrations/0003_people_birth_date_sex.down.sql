@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_people_sex;
+DROP INDEX IF EXISTS idx_people_birth_date;
+
+ALTER TABLE people DROP COLUMN IF EXISTS sex;
+ALTER TABLE people DROP COLUMN IF EXISTS birth_date;
@@ -0,0 +1,15 @@
+ALTER TABLE people ADD COLUMN IF NOT EXISTS birth_date DATE;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS sex TEXT CHECK (sex IN ('male', 'female'));
+
+-- Rows were validated with ValidateIIN on save, so the IIN always encodes a real date.
+UPDATE people SET
+    sex = CASE WHEN substr(iin, 7, 1)::int % 2 = 1 THEN 'male' ELSE 'female' END,
+    birth_date = make_date(
+        1800 + (substr(iin, 7, 1)::int - 1) / 2 * 100 + substr(iin, 1, 2)::int,
+        substr(iin, 3, 2)::int,
+        substr(iin, 5, 2)::int
+    )
+WHERE birth_date IS NULL AND substr(iin, 7, 1) BETWEEN '1' AND '6';
+
+CREATE INDEX IF NOT EXISTS idx_people_birth_date ON people (birth_date);
+CREATE INDEX IF NOT EXISTS idx_people_sex ON people (sex);
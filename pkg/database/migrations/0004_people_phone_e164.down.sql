@@ -0,0 +1 @@
+UPDATE people SET phone = substr(phone, 2) WHERE phone ~ '^\+7[0-9]{10}$';
@@ -0,0 +1,3 @@
+-- Normalize Kazakh numbers stored as 7XXXXXXXXXX, 8XXXXXXXXXX or XXXXXXXXXX to E.164.
+UPDATE people SET phone = '+7' || right(regexp_replace(phone, '[^0-9]', '', 'g'), 10)
+WHERE regexp_replace(phone, '[^0-9]', '', 'g') ~ '^([78]7[0-9]{9}|7[0-9]{9})$';
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_people_phone ON people (phone text_pattern_ops);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_people_name_trgm;
+DROP FUNCTION IF EXISTS people_name_key(TEXT);
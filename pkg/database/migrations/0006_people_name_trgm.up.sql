@@ -0,0 +1,8 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- people_name_key folds case and ё so that "Нурмедён" and "нурмеден" compare equal.
+CREATE OR REPLACE FUNCTION people_name_key(name TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT lower(translate(name, 'Ёё', 'Ее')) $$;
+
+CREATE INDEX IF NOT EXISTS idx_people_name_trgm ON people USING gin (people_name_key(name) gin_trgm_ops);
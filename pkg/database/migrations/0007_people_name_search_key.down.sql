@@ -0,0 +1,10 @@
+CREATE OR REPLACE FUNCTION people_name_key(name TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT lower(translate(name, 'Ёё', 'Ее')) $$;
+
+CREATE INDEX IF NOT EXISTS idx_people_name_trgm ON people USING gin (people_name_key(name) gin_trgm_ops);
+
+DROP INDEX IF EXISTS idx_people_name_search_key_missing;
+DROP INDEX IF EXISTS idx_people_name_search_key_trgm;
+
+ALTER TABLE people DROP COLUMN IF EXISTS name_search_key;
@@ -0,0 +1,9 @@
+-- name_search_key is computed by translit.SearchKey on save; existing rows are
+-- backfilled after migrating, by the server at startup and by `migrate up`.
+ALTER TABLE people ADD COLUMN IF NOT EXISTS name_search_key TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_people_name_search_key_trgm ON people USING gin (name_search_key gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_people_name_search_key_missing ON people (id) WHERE name_search_key IS NULL;
+
+DROP INDEX IF EXISTS idx_people_name_trgm;
+DROP FUNCTION IF EXISTS people_name_key(TEXT);
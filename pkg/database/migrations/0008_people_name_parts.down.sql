@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_people_first_name_missing;
+DROP INDEX IF EXISTS idx_people_middle_name_key_trgm;
+DROP INDEX IF EXISTS idx_people_first_name_key_trgm;
+DROP INDEX IF EXISTS idx_people_last_name_key_trgm;
+DROP INDEX IF EXISTS idx_people_last_first_name;
+
+ALTER TABLE people DROP COLUMN IF EXISTS middle_name_key;
+ALTER TABLE people DROP COLUMN IF EXISTS first_name_key;
+ALTER TABLE people DROP COLUMN IF EXISTS last_name_key;
+ALTER TABLE people DROP COLUMN IF EXISTS middle_name;
+ALTER TABLE people DROP COLUMN IF EXISTS first_name;
+ALTER TABLE people DROP COLUMN IF EXISTS last_name;
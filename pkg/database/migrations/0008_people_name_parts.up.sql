@@ -0,0 +1,14 @@
+-- Name parts and their search keys are filled by the application on save;
+-- existing rows are split heuristically by the backfill run after migrating.
+ALTER TABLE people ADD COLUMN IF NOT EXISTS last_name TEXT;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS first_name TEXT;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS middle_name TEXT;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS last_name_key TEXT;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS first_name_key TEXT;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS middle_name_key TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_people_last_first_name ON people (last_name, first_name);
+CREATE INDEX IF NOT EXISTS idx_people_last_name_key_trgm ON people USING gin (last_name_key gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_people_first_name_key_trgm ON people USING gin (first_name_key gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_people_middle_name_key_trgm ON people USING gin (middle_name_key gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_people_first_name_missing ON people (id) WHERE first_name IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_people_iin_pattern;
+DROP INDEX IF EXISTS idx_people_created_at;
+
+ALTER TABLE people DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,5 @@
+-- Rows that predate the column get the migration time as their creation time.
+ALTER TABLE people ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS idx_people_created_at ON people (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_people_iin_pattern ON people (iin bpchar_pattern_ops);
@@ -39,24 +39,3 @@ func ConnectPostgres() (*sql.DB, error) {
 	log.Println("Успешное подключение к базе данных")
 	return db, nil
 }
-
-func RunMigrations(db *sql.DB) {
-	migrations := []string{
-		`CREATE TABLE IF NOT EXISTS people (
-    		id SERIAL PRIMARY KEY,
-    		name TEXT NOT NULL,
-    		iin CHAR(12) UNIQUE NOT NULL CHECK (iin ~ '^[0-9]{12}$'), 
-    		phone VARCHAR(20) NOT NULL CHECK (phone ~ '^[0-9+\-() ]+$')
-		);`,
-		`CREATE INDEX IF NOT EXISTS idx_people_name ON people (name);
-		 CREATE INDEX IF NOT EXISTS idx_people_iin ON people (iin);`,
-	}
-
-	for _, query := range migrations {
-		_, err := db.Exec(query)
-		if err != nil {
-			log.Fatalf("Ошибка при выполнении миграции: %v", err)
-		}
-	}
-	log.Println("Миграции успешно выполнены")
-}
@@ -1,24 +1,74 @@
 package errors
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the non-standard status, popularised by nginx,
+// for a request the client abandoned before it was answered.
+const StatusClientClosedRequest = 499
 
 type AppError struct {
 	Code      int    `json:"code"`
 	Message   string `json:"message"`
 	IsDefault bool   `json:"is_default"`
+	Field     string `json:"field,omitempty"`
+	// ID is the id of the stored record a conflict is about.
+	ID int `json:"id,omitempty"`
 }
 
 func (e *AppError) Error() string {
 	return e.Message
 }
 
+// WithID returns a copy of the error pointing at the record with the given id.
+func (e *AppError) WithID(id int) *AppError {
+	copied := *e
+	copied.ID = id
+	return &copied
+}
+
 var (
-	ErrBadRequest         = &AppError{Code: http.StatusBadRequest, Message: "Invalid request data"}
-	ErrNotFound           = &AppError{Code: http.StatusNotFound, Message: "Resource not found"}
-	ErrInternalServer     = &AppError{Code: http.StatusInternalServerError, Message: "Internal server error"}
-	ErrInvalidIINLength   = &AppError{Code: http.StatusBadRequest, Message: "IIN must be exactly 12 digits"}
-	ErrInvalidIINFormat   = &AppError{Code: http.StatusBadRequest, Message: "IIN must contain only numeric digits"}
-	ErrInvalidIINChecksum = &AppError{Code: http.StatusBadRequest, Message: "Invalid IIN checksum"}
-	ErrInvalidDateOfBirth = &AppError{Code: http.StatusBadRequest, Message: "Invalid date of birth in IIN"}
-	ErrInvalidCenturyCode = &AppError{Code: http.StatusBadRequest, Message: "Invalid 7th digit in IIN"}
+	ErrBadRequest          = &AppError{Code: http.StatusBadRequest, Message: "Invalid request data"}
+	ErrNotFound            = &AppError{Code: http.StatusNotFound, Message: "Resource not found"}
+	ErrForbidden           = &AppError{Code: http.StatusForbidden, Message: "Admin privileges required"}
+	ErrInternalServer      = &AppError{Code: http.StatusInternalServerError, Message: "Internal server error"}
+	ErrInvalidIINLength    = &AppError{Code: http.StatusBadRequest, Message: "IIN must be exactly 12 digits"}
+	ErrInvalidIINFormat    = &AppError{Code: http.StatusBadRequest, Message: "IIN must contain only numeric digits"}
+	ErrInvalidIINChecksum  = &AppError{Code: http.StatusBadRequest, Message: "Invalid IIN checksum"}
+	ErrInvalidDateOfBirth  = &AppError{Code: http.StatusBadRequest, Message: "Invalid date of birth in IIN"}
+	ErrInvalidCenturyCode  = &AppError{Code: http.StatusBadRequest, Message: "Invalid 7th digit in IIN"}
+	ErrInvalidBINLength    = &AppError{Code: http.StatusBadRequest, Message: "BIN must be exactly 12 digits"}
+	ErrInvalidBINFormat    = &AppError{Code: http.StatusBadRequest, Message: "BIN must contain only numeric digits"}
+	ErrInvalidBINChecksum  = &AppError{Code: http.StatusBadRequest, Message: "Invalid BIN checksum"}
+	ErrInvalidBINDate      = &AppError{Code: http.StatusBadRequest, Message: "Invalid registration date in BIN"}
+	ErrInvalidEntityType   = &AppError{Code: http.StatusBadRequest, Message: "Invalid 5th digit in BIN"}
+	ErrInvalidBINDivision  = &AppError{Code: http.StatusBadRequest, Message: "Invalid 6th digit in BIN"}
+	ErrInvalidIDLength     = &AppError{Code: http.StatusBadRequest, Message: "Number must be exactly 12 digits"}
+	ErrInvalidIDFormat     = &AppError{Code: http.StatusBadRequest, Message: "Number must contain only numeric digits"}
+	ErrUnknownIDType       = &AppError{Code: http.StatusBadRequest, Message: "Number is neither a valid IIN nor a valid BIN"}
+	ErrInvalidPhoneFormat  = &AppError{Code: http.StatusBadRequest, Message: "Phone may contain only digits, a leading +, spaces, dashes, dots and parentheses", Field: "phone"}
+	ErrInvalidPhoneLength  = &AppError{Code: http.StatusBadRequest, Message: "Phone must have 10 digits, optionally prefixed with 7, 8 or +7", Field: "phone"}
+	ErrInvalidPhoneCountry = &AppError{Code: http.StatusBadRequest, Message: "Phone is not a Kazakhstan number", Field: "phone"}
+	ErrDuplicateIIN        = &AppError{Code: http.StatusConflict, Message: "Person with this IIN already exists", Field: "iin"}
+	ErrRequestCanceled     = &AppError{Code: StatusClientClosedRequest, Message: "Request was canceled by the client"}
+	ErrTimeout             = &AppError{Code: http.StatusGatewayTimeout, Message: "Request took too long and was aborted"}
+	ErrInvalidCursor       = &AppError{Code: http.StatusBadRequest, Message: "Cursor is malformed, tampered with or issued for another ordering", Field: "cursor"}
 )
+
+// FromContext reports err as ErrRequestCanceled or ErrTimeout when it was
+// caused by ctx being canceled or running past its deadline, and returns it
+// unchanged otherwise.
+func FromContext(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch ctx.Err() {
+	case context.Canceled:
+		return ErrRequestCanceled
+	case context.DeadlineExceeded:
+		return ErrTimeout
+	}
+	return err
+}